@@ -0,0 +1,127 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterIdentityClaimLabel is set on the IdentityClaims created for a
+// ClusterIdentityClaim to the name of that ClusterIdentityClaim.
+const ClusterIdentityClaimLabel = "identity.cluster.local/cluster-identity-claim"
+
+// ClusterIdentityClaimSpec defines the desired state of ClusterIdentityClaim.
+// It inherits the validation rules of the inlined IdentityClaimSpec.
+type ClusterIdentityClaimSpec struct {
+	// namespaceSelector selects the namespaces the identity is issued in.
+	// An empty selector matches every namespace.
+	// +required
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// The IdentityClaim created in each selected namespace gets these fields as its spec.
+	IdentityClaimSpec `json:",inline"`
+}
+
+// ClusterIdentityClaimNamespaceStatus is the state of the identity in one namespace.
+type ClusterIdentityClaimNamespaceStatus struct {
+	// namespace the IdentityClaim was created in.
+	// +required
+	Namespace string `json:"namespace"`
+
+	// phase of the IdentityClaim in the namespace.
+	// +optional
+	Phase IdentityClaimPhase `json:"phase,omitempty"`
+
+	// spiffeId assigned to the IdentityClaim in the namespace.
+	// +optional
+	SpiffeID string `json:"spiffeId,omitempty"`
+
+	// secretName is the name of the Secret holding the identity in the namespace.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// expiresAt is the timestamp when the certificate in the namespace expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// message explains why the identity is not ready in the namespace.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterIdentityClaimStatus defines the observed state of ClusterIdentityClaim.
+type ClusterIdentityClaimStatus struct {
+	// namespaces lists the state of the identity in every selected namespace.
+	// +optional
+	// +listType=map
+	// +listMapKey=namespace
+	Namespaces []ClusterIdentityClaimNamespaceStatus `json:"namespaces,omitempty"`
+
+	// totalNamespaces is the number of namespaces matching namespaceSelector.
+	// +optional
+	TotalNamespaces int32 `json:"totalNamespaces,omitempty"`
+
+	// readyNamespaces is the number of namespaces whose identity is Ready.
+	// +optional
+	ReadyNamespaces int32 `json:"readyNamespaces,omitempty"`
+
+	// conditions represent the current state of the ClusterIdentityClaim resource.
+	// Condition types: Ready
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Namespaces",type="integer",JSONPath=".status.totalNamespaces",description="Selected namespaces"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyNamespaces",description="Namespaces with a Ready identity"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterIdentityClaim is the Schema for the clusteridentityclaims API.
+// It issues the same identity in every namespace selected by its namespaceSelector
+// by managing one IdentityClaim of the same name per namespace.
+type ClusterIdentityClaim struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of ClusterIdentityClaim
+	// +required
+	Spec ClusterIdentityClaimSpec `json:"spec"`
+
+	// status defines the observed state of ClusterIdentityClaim
+	// +optional
+	Status ClusterIdentityClaimStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterIdentityClaimList contains a list of ClusterIdentityClaim
+type ClusterIdentityClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []ClusterIdentityClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterIdentityClaim{}, &ClusterIdentityClaimList{})
+}
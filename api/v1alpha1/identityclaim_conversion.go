@@ -0,0 +1,161 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/osagberg/identity-claim-operator/api/v1beta1"
+)
+
+// ConvertTo converts this IdentityClaim to the Hub version (v1beta1).
+func (src *IdentityClaim) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.IdentityClaim)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = v1beta1.IdentityClaimSpec{
+		Selector: spec.Selector,
+		Certificate: v1beta1.CertificateSpec{
+			TTL:                   spec.TTL,
+			RenewBefore:           spec.RenewBefore,
+			RenewBeforePercentage: spec.RenewBeforePercentage,
+			IssuerRef:             (*v1beta1.IssuerReference)(spec.IssuerRef),
+			DNSNames:              (*v1beta1.DNSNames)(spec.DNSNames),
+			PrivateKey:            privateKeyToHub(spec.PrivateKey),
+		},
+		Secret: v1beta1.SecretSpec{
+			Name: spec.SecretName,
+		},
+		SPIFFE: v1beta1.SPIFFESpec{
+			Path: spec.SpiffeIDPath,
+		},
+		DeletionPolicy:      v1beta1.DeletionPolicy(spec.DeletionPolicy),
+		DeletionGracePeriod: spec.DeletionGracePeriod,
+		Suspend:             spec.Suspend,
+	}
+	if secret := spec.Secret; secret != nil {
+		dst.Spec.Secret.Labels = secret.Labels
+		dst.Spec.Secret.Annotations = secret.Annotations
+		dst.Spec.Secret.Keystores = convertStrings[v1beta1.KeystoreType](secret.Keystores)
+		dst.Spec.Secret.PasswordSecretRef = (*v1beta1.SecretKeySelector)(secret.PasswordSecretRef)
+		dst.Spec.Secret.AdditionalOutputFormats = convertStrings[v1beta1.OutputFormat](secret.AdditionalOutputFormats)
+	}
+
+	status := src.Status.DeepCopy()
+	dst.Status = v1beta1.IdentityClaimStatus{
+		Phase:               v1beta1.IdentityClaimPhase(status.Phase),
+		SpiffeID:            status.SpiffeID,
+		TrustDomain:         status.TrustDomain,
+		SecretName:          status.SecretName,
+		SecretKeys:          status.SecretKeys,
+		ExpiresAt:           status.ExpiresAt,
+		DeletionDeadline:    status.DeletionDeadline,
+		LastRotationTime:    status.LastRotationTime,
+		LastRotationReason:  status.LastRotationReason,
+		LastRotationRequest: status.LastRotationRequest,
+		Conditions:          status.Conditions,
+	}
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1beta1) to this IdentityClaim.
+func (dst *IdentityClaim) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.IdentityClaim)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = IdentityClaimSpec{
+		Selector:              spec.Selector,
+		TTL:                   spec.Certificate.TTL,
+		RenewBefore:           spec.Certificate.RenewBefore,
+		RenewBeforePercentage: spec.Certificate.RenewBeforePercentage,
+		IssuerRef:             (*IssuerReference)(spec.Certificate.IssuerRef),
+		DNSNames:              (*DNSNames)(spec.Certificate.DNSNames),
+		SecretName:            spec.Secret.Name,
+		PrivateKey:            privateKeyFromHub(spec.Certificate.PrivateKey),
+		SpiffeIDPath:          spec.SPIFFE.Path,
+		DeletionPolicy:        DeletionPolicy(spec.DeletionPolicy),
+		DeletionGracePeriod:   spec.DeletionGracePeriod,
+		Suspend:               spec.Suspend,
+	}
+	// v1alpha1 keeps the Secret's name outside of spec.secret, which is only
+	// set when something else about the Secret is configured
+	if secret := spec.Secret; secret.Labels != nil || secret.Annotations != nil || secret.Keystores != nil ||
+		secret.PasswordSecretRef != nil || secret.AdditionalOutputFormats != nil {
+		dst.Spec.Secret = &SecretSpec{
+			Labels:                  secret.Labels,
+			Annotations:             secret.Annotations,
+			Keystores:               convertStrings[KeystoreType](secret.Keystores),
+			PasswordSecretRef:       (*SecretKeySelector)(secret.PasswordSecretRef),
+			AdditionalOutputFormats: convertStrings[OutputFormat](secret.AdditionalOutputFormats),
+		}
+	}
+
+	status := src.Status.DeepCopy()
+	dst.Status = IdentityClaimStatus{
+		Phase:               IdentityClaimPhase(status.Phase),
+		SpiffeID:            status.SpiffeID,
+		TrustDomain:         status.TrustDomain,
+		SecretName:          status.SecretName,
+		SecretKeys:          status.SecretKeys,
+		ExpiresAt:           status.ExpiresAt,
+		DeletionDeadline:    status.DeletionDeadline,
+		LastRotationTime:    status.LastRotationTime,
+		LastRotationReason:  status.LastRotationReason,
+		LastRotationRequest: status.LastRotationRequest,
+		Conditions:          status.Conditions,
+	}
+	return nil
+}
+
+func privateKeyToHub(key *PrivateKey) *v1beta1.PrivateKey {
+	if key == nil {
+		return nil
+	}
+	return &v1beta1.PrivateKey{
+		Algorithm:      v1beta1.PrivateKeyAlgorithm(key.Algorithm),
+		Size:           key.Size,
+		Encoding:       v1beta1.PrivateKeyEncoding(key.Encoding),
+		RotationPolicy: v1beta1.PrivateKeyRotationPolicy(key.RotationPolicy),
+	}
+}
+
+func privateKeyFromHub(key *v1beta1.PrivateKey) *PrivateKey {
+	if key == nil {
+		return nil
+	}
+	return &PrivateKey{
+		Algorithm:      PrivateKeyAlgorithm(key.Algorithm),
+		Size:           key.Size,
+		Encoding:       PrivateKeyEncoding(key.Encoding),
+		RotationPolicy: PrivateKeyRotationPolicy(key.RotationPolicy),
+	}
+}
+
+// convertStrings converts a list of string enums between versions, keeping nil
+// lists nil.
+func convertStrings[To, From ~string](from []From) []To {
+	if from == nil {
+		return nil
+	}
+	to := make([]To, len(from))
+	for i, v := range from {
+		to[i] = To(v)
+	}
+	return to
+}
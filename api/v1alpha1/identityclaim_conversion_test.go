@@ -0,0 +1,92 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/randfill"
+
+	"github.com/osagberg/identity-claim-operator/api/v1beta1"
+)
+
+// fuzzIterations is how many random IdentityClaims each round trip converts.
+const fuzzIterations = 1000
+
+var _ = Describe("IdentityClaim conversion", func() {
+	var filler *randfill.Filler
+
+	BeforeEach(func() {
+		filler = randfill.New().NilChance(0.3).NumElements(0, 3)
+	})
+
+	It("should round-trip v1alpha1 through v1beta1", func() {
+		for range fuzzIterations {
+			original := &IdentityClaim{}
+			filler.Fill(original)
+			// The conversion webhook sets the TypeMeta of converted objects
+			original.TypeMeta = metav1.TypeMeta{}
+			// An empty spec.secret configures nothing and has no v1beta1 equivalent
+			if original.Spec.Secret != nil && apiequality.Semantic.DeepEqual(*original.Spec.Secret, SecretSpec{}) {
+				original.Spec.Secret = nil
+			}
+
+			hub := &v1beta1.IdentityClaim{}
+			Expect(original.DeepCopy().ConvertTo(hub)).To(Succeed())
+			converted := &IdentityClaim{}
+			Expect(converted.ConvertFrom(hub)).To(Succeed())
+
+			Expect(apiequality.Semantic.DeepEqual(original, converted)).To(BeTrue(),
+				"round trip changed the object:\n%#v\n%#v", original, converted)
+		}
+	})
+
+	It("should round-trip v1beta1 through v1alpha1", func() {
+		for range fuzzIterations {
+			original := &v1beta1.IdentityClaim{}
+			filler.Fill(original)
+			original.TypeMeta = metav1.TypeMeta{}
+
+			spoke := &IdentityClaim{}
+			Expect(spoke.ConvertFrom(original.DeepCopy())).To(Succeed())
+			converted := &v1beta1.IdentityClaim{}
+			Expect(spoke.ConvertTo(converted)).To(Succeed())
+
+			Expect(apiequality.Semantic.DeepEqual(original, converted)).To(BeTrue(),
+				"round trip changed the object:\n%#v\n%#v", original, converted)
+		}
+	})
+
+	It("should move the Secret's name into spec.secret", func() {
+		claim := &IdentityClaim{Spec: IdentityClaimSpec{
+			SecretName:   "payments-tls",
+			SpiffeIDPath: "ns/payments/sa/api",
+		}}
+
+		hub := &v1beta1.IdentityClaim{}
+		Expect(claim.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Secret.Name).To(Equal("payments-tls"))
+		Expect(hub.Spec.SPIFFE.Path).To(Equal("ns/payments/sa/api"))
+
+		converted := &IdentityClaim{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted.Spec.SecretName).To(Equal("payments-tls"))
+		Expect(converted.Spec.Secret).To(BeNil())
+	})
+})
@@ -17,9 +17,24 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Annotations requesting an immediate rotation of a claim's private key and
+// certificate.
+const (
+	// RotateRequestedAtAnnotation requests a rotation when set to an RFC 3339
+	// timestamp later than the one of the last rotation.
+	RotateRequestedAtAnnotation = "identity.cluster.local/rotate-requested-at"
+	// RotateReasonAnnotation describes why the rotation was requested.
+	RotateReasonAnnotation = "identity.cluster.local/rotate-reason"
+)
+
+// ClaimLabel names the IdentityClaim a CertificateRequest of a CSI volume was
+// made for; these requests count toward the namespace's issuance rate.
+const ClaimLabel = "identity.cluster.local/claim"
+
 // IssuerReference identifies a cert-manager issuer.
 type IssuerReference struct {
 	// name of the issuer resource.
@@ -35,7 +50,158 @@ type IssuerReference struct {
 	Group string `json:"group,omitempty"`
 }
 
+// PrivateKeyAlgorithm is the algorithm of the certificate's private key.
+// +kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+type PrivateKeyAlgorithm string
+
+const (
+	// KeyAlgorithmRSA generates an RSA private key
+	KeyAlgorithmRSA PrivateKeyAlgorithm = "RSA"
+	// KeyAlgorithmECDSA generates an ECDSA private key
+	KeyAlgorithmECDSA PrivateKeyAlgorithm = "ECDSA"
+	// KeyAlgorithmEd25519 generates an Ed25519 private key
+	KeyAlgorithmEd25519 PrivateKeyAlgorithm = "Ed25519"
+)
+
+// PrivateKeyEncoding is the PKCS encoding of the private key in the Secret.
+// +kubebuilder:validation:Enum=PKCS1;PKCS8
+type PrivateKeyEncoding string
+
+const (
+	// KeyEncodingPKCS1 encodes the key as PKCS#1 (SEC 1 for ECDSA keys)
+	KeyEncodingPKCS1 PrivateKeyEncoding = "PKCS1"
+	// KeyEncodingPKCS8 encodes the key as PKCS#8
+	KeyEncodingPKCS8 PrivateKeyEncoding = "PKCS8"
+)
+
+// PrivateKeyRotationPolicy controls whether a new key is generated on re-issuance.
+// +kubebuilder:validation:Enum=Always;Never
+type PrivateKeyRotationPolicy string
+
+const (
+	// RotationPolicyAlways generates a new private key on every re-issuance
+	RotationPolicyAlways PrivateKeyRotationPolicy = "Always"
+	// RotationPolicyNever reuses the existing private key on re-issuance
+	RotationPolicyNever PrivateKeyRotationPolicy = "Never"
+)
+
+// PrivateKey configures the private key of the issued certificate.
+// +kubebuilder:validation:XValidation:rule="self.algorithm != 'Ed25519' || !has(self.size)",message="size must not be set for Ed25519 keys"
+// +kubebuilder:validation:XValidation:rule="self.algorithm != 'ECDSA' || !has(self.size) || self.size in [256, 384, 521]",message="ECDSA key size must be 256, 384 or 521"
+// +kubebuilder:validation:XValidation:rule="self.algorithm != 'RSA' || !has(self.size) || (self.size >= 2048 && self.size <= 8192)",message="RSA key size must be between 2048 and 8192"
+type PrivateKey struct {
+	// algorithm of the private key.
+	// +optional
+	// +kubebuilder:default="ECDSA"
+	Algorithm PrivateKeyAlgorithm `json:"algorithm,omitempty"`
+
+	// size of the private key in bits. Defaults to 256 for ECDSA and 2048 for RSA.
+	// Must not be set for Ed25519.
+	// +optional
+	Size int `json:"size,omitempty"`
+
+	// encoding of the private key in the Secret. Defaults to PKCS1.
+	// Ed25519 keys have no PKCS1 form and are always written as PKCS8.
+	// +optional
+	Encoding PrivateKeyEncoding `json:"encoding,omitempty"`
+
+	// rotationPolicy controls whether a new private key is generated on re-issuance.
+	// Defaults to cert-manager's default when unset.
+	// +optional
+	RotationPolicy PrivateKeyRotationPolicy `json:"rotationPolicy,omitempty"`
+}
+
+// DNSNames configures the DNS and IP subject alternative names of the certificate.
+type DNSNames struct {
+	// names is an explicit list of DNS names to include.
+	// +optional
+	// +listType=set
+	Names []string `json:"names,omitempty"`
+
+	// fromServices adds <svc>, <svc>.<ns>, <svc>.<ns>.svc and <svc>.<ns>.svc.<cluster-domain>
+	// plus the cluster IPs of every Service in the namespace that selects the claim's pods.
+	// +optional
+	FromServices bool `json:"fromServices,omitempty"`
+}
+
+// KeystoreType is a keystore format written to the identity Secret.
+// +kubebuilder:validation:Enum=PKCS12;JKS
+type KeystoreType string
+
+const (
+	// KeystorePKCS12 writes keystore.p12 and truststore.p12
+	KeystorePKCS12 KeystoreType = "PKCS12"
+	// KeystoreJKS writes keystore.jks and truststore.jks
+	KeystoreJKS KeystoreType = "JKS"
+)
+
+// OutputFormat is an additional output format written to the identity Secret.
+// +kubebuilder:validation:Enum=CombinedPEM;DER
+type OutputFormat string
+
+const (
+	// OutputFormatCombinedPEM writes the private key and certificate chain to tls-combined.pem
+	OutputFormatCombinedPEM OutputFormat = "CombinedPEM"
+	// OutputFormatDER writes the DER encoded private key to key.der
+	OutputFormatDER OutputFormat = "DER"
+)
+
+// DeletionPolicy decides what happens to the Certificate and the identity
+// Secret when their IdentityClaim is deleted.
+// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Certificate and the Secret
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain deletes the Certificate but keeps the Secret, which
+	// stays usable until the certificate in it expires
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan keeps the Certificate and the Secret, so cert-manager
+	// keeps renewing the certificate without the claim
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// SecretKeySelector selects a key of a Secret in the claim's namespace.
+type SecretKeySelector struct {
+	// name of the Secret.
+	// +required
+	Name string `json:"name"`
+	// key within the Secret.
+	// +required
+	Key string `json:"key"`
+}
+
+// SecretSpec configures the Secret holding the issued identity.
+// +kubebuilder:validation:XValidation:rule="!has(self.keystores) || size(self.keystores) == 0 || has(self.passwordSecretRef)",message="passwordSecretRef is required when keystores are set"
+type SecretSpec struct {
+	// labels to add to the Secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// annotations to add to the Secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// keystores lists keystore formats to write alongside the PEM files.
+	// +optional
+	// +listType=set
+	Keystores []KeystoreType `json:"keystores,omitempty"`
+
+	// passwordSecretRef references the password used to encrypt the keystores.
+	// +optional
+	PasswordSecretRef *SecretKeySelector `json:"passwordSecretRef,omitempty"`
+
+	// additionalOutputFormats lists extra encodings of the key and certificate.
+	// +optional
+	// +listType=set
+	AdditionalOutputFormats []OutputFormat `json:"additionalOutputFormats,omitempty"`
+}
+
 // IdentityClaimSpec defines the desired state of IdentityClaim
+// +kubebuilder:validation:XValidation:rule="!(has(self.renewBefore) && has(self.renewBeforePercentage))",message="renewBefore and renewBeforePercentage are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!has(self.renewBefore) || duration(self.renewBefore) < duration(self.ttl)",message="renewBefore must be shorter than ttl"
+// +kubebuilder:validation:XValidation:rule="has(self.secretName) == has(oldSelf.secretName)",message="secretName is immutable"
 type IdentityClaimSpec struct {
 	// selector specifies which pods should receive the identity.
 	// Pods matching these labels will have access to the generated TLS certificate.
@@ -50,9 +216,76 @@ type IdentityClaimSpec struct {
 	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('5m') && duration(self) <= duration('8760h')",message="TTL must be between 5m and 8760h"
 	TTL metav1.Duration `json:"ttl,omitempty"`
 
+	// renewBefore is how long before expiry the certificate is renewed.
+	// Must be at least 5m, cert-manager's minimum, and shorter than ttl.
+	// Defaults to a third of ttl, raised to 5m for TTLs shorter than 15m.
+	// +optional
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('5m')",message="renewBefore must be at least 5m"
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+
+	// renewBeforePercentage is how long before expiry the certificate is renewed,
+	// as a percentage of ttl. Mutually exclusive with renewBefore. The resulting
+	// duration must be at least 5m.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	RenewBeforePercentage *int32 `json:"renewBeforePercentage,omitempty"`
+
 	// issuerRef overrides the default certificate issuer.
 	// +optional
 	IssuerRef *IssuerReference `json:"issuerRef,omitempty"`
+
+	// dnsNames opts into DNS and IP SANs so the Secret can also serve plain HTTPS.
+	// By default the certificate only carries the SPIFFE ID.
+	// +optional
+	DNSNames *DNSNames `json:"dnsNames,omitempty"`
+
+	// secretName is the name of the Secret holding the identity and of the
+	// Certificate that issues it. Defaults to <name>-identity. Immutable.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="secretName is immutable"
+	SecretName string `json:"secretName,omitempty"`
+
+	// secret configures metadata and additional formats of the identity Secret.
+	// +optional
+	Secret *SecretSpec `json:"secret,omitempty"`
+
+	// privateKey configures the private key of the certificate.
+	// Defaults to an ECDSA P-256 key.
+	// +optional
+	PrivateKey *PrivateKey `json:"privateKey,omitempty"`
+
+	// spiffeIDPath is a Go template for the path of the SPIFFE ID, overriding the
+	// operator's --spiffe-id-path-template. Available fields are .Namespace, .Name,
+	// .ServiceAccount and .Labels; pod attributes are only set when every selected
+	// pod agrees on them. Example: ns/{{.Namespace}}/sa/{{.ServiceAccount}}
+	// +optional
+	// +kubebuilder:validation:MaxLength=512
+	SpiffeIDPath string `json:"spiffeIDPath,omitempty"`
+
+	// deletionPolicy decides what happens to the Certificate and the identity
+	// Secret when the claim is deleted: Delete removes both, Retain keeps the
+	// Secret, and Orphan keeps both and lets cert-manager keep renewing.
+	// +optional
+	// +kubebuilder:default="Delete"
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// deletionGracePeriod is how long a deleted claim keeps its Certificate and
+	// Secret while selected pods are still running, so pods can be rolled out
+	// before they lose their identity. Not used with deletionPolicy Orphan.
+	// +optional
+	// +kubebuilder:validation:Format=duration
+	DeletionGracePeriod *metav1.Duration `json:"deletionGracePeriod,omitempty"`
+
+	// suspend stops the operator from reconciling the claim, leaving its
+	// Certificate, Secret and status as they are until it is unset.
+	// cert-manager still renews the Certificate when it is due. Deleting a
+	// suspended claim still applies its deletionPolicy.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // IdentityClaimPhase represents the current phase of the IdentityClaim
@@ -78,6 +311,14 @@ const (
 	ConditionCertificateIssued = "CertificateIssued"
 	// ConditionPodsVerified indicates matching pods were found
 	ConditionPodsVerified = "PodsVerified"
+	// ConditionSelectorConflict indicates other claims select some of the same pods
+	ConditionSelectorConflict = "SelectorConflict"
+	// ConditionPolicyViolation indicates an IdentityPolicy denies the claim
+	ConditionPolicyViolation = "PolicyViolation"
+	// ConditionQuotaExceeded indicates the namespace's quota holds back the claim
+	ConditionQuotaExceeded = "QuotaExceeded"
+	// ConditionSuspended indicates spec.suspend stops the claim from being reconciled
+	ConditionSuspended = "Suspended"
 )
 
 // IdentityClaimStatus defines the observed state of IdentityClaim.
@@ -87,20 +328,49 @@ type IdentityClaimStatus struct {
 	Phase IdentityClaimPhase `json:"phase,omitempty"`
 
 	// spiffeId is the SPIFFE identity URI assigned to this claim.
-	// Format: spiffe://cluster.local/ns/<namespace>/ic/<name>
+	// Format: spiffe://<trust-domain>/<path>, where the path defaults to ns/<namespace>/ic/<name>
 	// +optional
 	SpiffeID string `json:"spiffeId,omitempty"`
 
+	// trustDomain is the SPIFFE trust domain the identity was issued under.
+	// +optional
+	TrustDomain string `json:"trustDomain,omitempty"`
+
 	// secretName is the name of the Secret containing the TLS certificate.
 	// +optional
 	SecretName string `json:"secretName,omitempty"`
 
+	// secretKeys lists the data keys written to the Secret. ca.crt and the
+	// truststore entries are only present when the issuer returns a CA certificate.
+	// +optional
+	// +listType=atomic
+	SecretKeys []string `json:"secretKeys,omitempty"`
+
 	// expiresAt is the timestamp when the current certificate expires.
 	// +optional
 	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
 
+	// deletionDeadline is when a deleted claim stops waiting for its selected
+	// pods and removes its Certificate and Secret as its deletionPolicy says.
+	// +optional
+	DeletionDeadline *metav1.Time `json:"deletionDeadline,omitempty"`
+
+	// lastRotationTime is when the certificate was last re-issued on request
+	// of the rotate-requested-at annotation.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// lastRotationReason is the rotate-reason annotation of the last rotation.
+	// +optional
+	LastRotationReason string `json:"lastRotationReason,omitempty"`
+
+	// lastRotationRequest is the rotate-requested-at annotation of the last
+	// rotation. Requests with the same or an earlier timestamp are ignored.
+	// +optional
+	LastRotationRequest string `json:"lastRotationRequest,omitempty"`
+
 	// conditions represent the current state of the IdentityClaim resource.
-	// Condition types: Ready, CertificateIssued, PodsVerified
+	// Condition types: Ready, CertificateIssued, PodsVerified, SelectorConflict, PolicyViolation, QuotaExceeded, Suspended
 	// +listType=map
 	// +listMapKey=type
 	// +optional
@@ -132,6 +402,28 @@ type IdentityClaim struct {
 	Status IdentityClaimStatus `json:"status,omitzero"`
 }
 
+// IdentitySecretName returns the name of the Secret holding the claim's identity:
+// status.secretName once assigned, otherwise spec.secretName or <name>-identity.
+func (c *IdentityClaim) IdentitySecretName() string {
+	if c.Status.SecretName != "" {
+		return c.Status.SecretName
+	}
+	if c.Spec.SecretName != "" {
+		return c.Spec.SecretName
+	}
+	return c.Name + "-identity"
+}
+
+// Servable reports whether the operator considers the claim's identity usable:
+// it is Ready, and neither failed nor suspended since. Suspending a claim and
+// some failures leave the Ready condition as it was.
+func (c *IdentityClaim) Servable() bool {
+	return meta.IsStatusConditionTrue(c.Status.Conditions, ConditionReady) &&
+		c.Status.Phase != PhaseFailed &&
+		!c.Spec.Suspend &&
+		!meta.IsStatusConditionTrue(c.Status.Conditions, ConditionSuspended)
+}
+
 // +kubebuilder:object:root=true
 
 // IdentityClaimList contains a list of IdentityClaim
@@ -0,0 +1,121 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Namespace annotations overriding the quota of IdentityPolicies and the
+// operator's defaults. A value of 0 lifts the limit for the namespace.
+const (
+	// MaxClaimsAnnotation limits the number of IdentityClaims in the namespace.
+	MaxClaimsAnnotation = "identity.cluster.local/max-claims"
+	// MaxIssuancesPerHourAnnotation limits the certificates issued in the namespace per hour.
+	MaxIssuancesPerHourAnnotation = "identity.cluster.local/max-issuances-per-hour"
+)
+
+// IdentityQuota limits the IdentityClaims of a namespace.
+type IdentityQuota struct {
+	// maxClaims is the number of IdentityClaims the namespace may have.
+	// Claims beyond it, youngest first, are not issued.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxClaims *int32 `json:"maxClaims,omitempty"`
+
+	// maxIssuancesPerHour is the number of certificates that may be issued in
+	// the namespace per hour, renewals included. New claims wait until the
+	// rate allows them to be issued.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxIssuancesPerHour *int32 `json:"maxIssuancesPerHour,omitempty"`
+}
+
+// IdentityPolicySpec defines the desired state of IdentityPolicy.
+// Unset constraints allow anything.
+// +kubebuilder:validation:XValidation:rule="!has(self.minTTL) || !has(self.maxTTL) || duration(self.minTTL) <= duration(self.maxTTL)",message="minTTL must not be longer than maxTTL"
+type IdentityPolicySpec struct {
+	// namespaceSelector selects the namespaces whose IdentityClaims the policy
+	// applies to. An empty selector matches every namespace.
+	// +required
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// allowedIssuers lists the issuers claims may reference. Kind and group
+	// default as in spec.issuerRef of IdentityClaim.
+	// +optional
+	// +listType=atomic
+	AllowedIssuers []IssuerReference `json:"allowedIssuers,omitempty"`
+
+	// minTTL is the shortest ttl claims may request.
+	// +optional
+	// +kubebuilder:validation:Format=duration
+	MinTTL *metav1.Duration `json:"minTTL,omitempty"`
+
+	// maxTTL is the longest ttl claims may request.
+	// +optional
+	// +kubebuilder:validation:Format=duration
+	MaxTTL *metav1.Duration `json:"maxTTL,omitempty"`
+
+	// allowedSpiffeIDPaths lists glob patterns the SPIFFE ID path must match,
+	// without the leading slash. * matches within a single path segment, for
+	// example ns/team-a/*.
+	// +optional
+	// +listType=set
+	AllowedSpiffeIDPaths []string `json:"allowedSpiffeIDPaths,omitempty"`
+
+	// allowedKeyAlgorithms lists the private key algorithms claims may use.
+	// +optional
+	// +listType=set
+	AllowedKeyAlgorithms []PrivateKeyAlgorithm `json:"allowedKeyAlgorithms,omitempty"`
+
+	// quota limits the IdentityClaims of each selected namespace. When several
+	// policies select a namespace, the lowest limit applies.
+	// +optional
+	Quota *IdentityQuota `json:"quota,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// IdentityPolicy is the Schema for the identitypolicies API.
+// It constrains the IdentityClaims of the namespaces it selects; a claim must
+// satisfy every policy that applies to its namespace.
+type IdentityPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the constraints of IdentityPolicy
+	// +required
+	Spec IdentityPolicySpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// IdentityPolicyList contains a list of IdentityPolicy
+type IdentityPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []IdentityPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IdentityPolicy{}, &IdentityPolicyList{})
+}
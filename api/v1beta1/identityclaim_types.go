@@ -0,0 +1,399 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IssuerReference identifies a cert-manager issuer.
+type IssuerReference struct {
+	// name of the issuer resource.
+	// +required
+	Name string `json:"name"`
+	// kind of the issuer (Issuer or ClusterIssuer).
+	// +optional
+	// +kubebuilder:default="ClusterIssuer"
+	Kind string `json:"kind,omitempty"`
+	// group of the issuer.
+	// +optional
+	// +kubebuilder:default="cert-manager.io"
+	Group string `json:"group,omitempty"`
+}
+
+// PrivateKeyAlgorithm is the algorithm of the certificate's private key.
+// +kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+type PrivateKeyAlgorithm string
+
+const (
+	// KeyAlgorithmRSA generates an RSA private key
+	KeyAlgorithmRSA PrivateKeyAlgorithm = "RSA"
+	// KeyAlgorithmECDSA generates an ECDSA private key
+	KeyAlgorithmECDSA PrivateKeyAlgorithm = "ECDSA"
+	// KeyAlgorithmEd25519 generates an Ed25519 private key
+	KeyAlgorithmEd25519 PrivateKeyAlgorithm = "Ed25519"
+)
+
+// PrivateKeyEncoding is the PKCS encoding of the private key in the Secret.
+// +kubebuilder:validation:Enum=PKCS1;PKCS8
+type PrivateKeyEncoding string
+
+const (
+	// KeyEncodingPKCS1 encodes the key as PKCS#1 (SEC 1 for ECDSA keys)
+	KeyEncodingPKCS1 PrivateKeyEncoding = "PKCS1"
+	// KeyEncodingPKCS8 encodes the key as PKCS#8
+	KeyEncodingPKCS8 PrivateKeyEncoding = "PKCS8"
+)
+
+// PrivateKeyRotationPolicy controls whether a new key is generated on re-issuance.
+// +kubebuilder:validation:Enum=Always;Never
+type PrivateKeyRotationPolicy string
+
+const (
+	// RotationPolicyAlways generates a new private key on every re-issuance
+	RotationPolicyAlways PrivateKeyRotationPolicy = "Always"
+	// RotationPolicyNever reuses the existing private key on re-issuance
+	RotationPolicyNever PrivateKeyRotationPolicy = "Never"
+)
+
+// PrivateKey configures the private key of the issued certificate.
+// +kubebuilder:validation:XValidation:rule="self.algorithm != 'Ed25519' || !has(self.size)",message="size must not be set for Ed25519 keys"
+// +kubebuilder:validation:XValidation:rule="self.algorithm != 'ECDSA' || !has(self.size) || self.size in [256, 384, 521]",message="ECDSA key size must be 256, 384 or 521"
+// +kubebuilder:validation:XValidation:rule="self.algorithm != 'RSA' || !has(self.size) || (self.size >= 2048 && self.size <= 8192)",message="RSA key size must be between 2048 and 8192"
+type PrivateKey struct {
+	// algorithm of the private key.
+	// +optional
+	// +kubebuilder:default="ECDSA"
+	Algorithm PrivateKeyAlgorithm `json:"algorithm,omitempty"`
+
+	// size of the private key in bits. Defaults to 256 for ECDSA and 2048 for RSA.
+	// Must not be set for Ed25519.
+	// +optional
+	Size int `json:"size,omitempty"`
+
+	// encoding of the private key in the Secret. Defaults to PKCS1.
+	// Ed25519 keys have no PKCS1 form and are always written as PKCS8.
+	// +optional
+	Encoding PrivateKeyEncoding `json:"encoding,omitempty"`
+
+	// rotationPolicy controls whether a new private key is generated on re-issuance.
+	// Defaults to cert-manager's default when unset.
+	// +optional
+	RotationPolicy PrivateKeyRotationPolicy `json:"rotationPolicy,omitempty"`
+}
+
+// DNSNames configures the DNS and IP subject alternative names of the certificate.
+type DNSNames struct {
+	// names is an explicit list of DNS names to include.
+	// +optional
+	// +listType=set
+	Names []string `json:"names,omitempty"`
+
+	// fromServices adds <svc>, <svc>.<ns>, <svc>.<ns>.svc and <svc>.<ns>.svc.<cluster-domain>
+	// plus the cluster IPs of every Service in the namespace that selects the claim's pods.
+	// +optional
+	FromServices bool `json:"fromServices,omitempty"`
+}
+
+// CertificateSpec configures the certificate issued for the claim.
+// +kubebuilder:validation:XValidation:rule="!(has(self.renewBefore) && has(self.renewBeforePercentage))",message="renewBefore and renewBeforePercentage are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!has(self.renewBefore) || duration(self.renewBefore) < duration(self.ttl)",message="renewBefore must be shorter than ttl"
+type CertificateSpec struct {
+	// ttl specifies how long the certificate should be valid.
+	// Defaults to 1h if not specified. Must be between 5m and 8760h.
+	// +optional
+	// +kubebuilder:default="1h"
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('5m') && duration(self) <= duration('8760h')",message="TTL must be between 5m and 8760h"
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// renewBefore is how long before expiry the certificate is renewed.
+	// Must be at least 5m, cert-manager's minimum, and shorter than ttl.
+	// Defaults to a third of ttl, raised to 5m for TTLs shorter than 15m.
+	// +optional
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('5m')",message="renewBefore must be at least 5m"
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+
+	// renewBeforePercentage is how long before expiry the certificate is renewed,
+	// as a percentage of ttl. Mutually exclusive with renewBefore. The resulting
+	// duration must be at least 5m.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	RenewBeforePercentage *int32 `json:"renewBeforePercentage,omitempty"`
+
+	// issuerRef overrides the default certificate issuer.
+	// +optional
+	IssuerRef *IssuerReference `json:"issuerRef,omitempty"`
+
+	// dnsNames opts into DNS and IP SANs so the Secret can also serve plain HTTPS.
+	// By default the certificate only carries the SPIFFE ID.
+	// +optional
+	DNSNames *DNSNames `json:"dnsNames,omitempty"`
+
+	// privateKey configures the private key of the certificate.
+	// Defaults to an ECDSA P-256 key.
+	// +optional
+	PrivateKey *PrivateKey `json:"privateKey,omitempty"`
+}
+
+// KeystoreType is a keystore format written to the identity Secret.
+// +kubebuilder:validation:Enum=PKCS12;JKS
+type KeystoreType string
+
+const (
+	// KeystorePKCS12 writes keystore.p12 and truststore.p12
+	KeystorePKCS12 KeystoreType = "PKCS12"
+	// KeystoreJKS writes keystore.jks and truststore.jks
+	KeystoreJKS KeystoreType = "JKS"
+)
+
+// OutputFormat is an additional output format written to the identity Secret.
+// +kubebuilder:validation:Enum=CombinedPEM;DER
+type OutputFormat string
+
+const (
+	// OutputFormatCombinedPEM writes the private key and certificate chain to tls-combined.pem
+	OutputFormatCombinedPEM OutputFormat = "CombinedPEM"
+	// OutputFormatDER writes the DER encoded private key to key.der
+	OutputFormatDER OutputFormat = "DER"
+)
+
+// SecretKeySelector selects a key of a Secret in the claim's namespace.
+type SecretKeySelector struct {
+	// name of the Secret.
+	// +required
+	Name string `json:"name"`
+	// key within the Secret.
+	// +required
+	Key string `json:"key"`
+}
+
+// SecretSpec configures the Secret holding the issued identity.
+// +kubebuilder:validation:XValidation:rule="!has(self.keystores) || size(self.keystores) == 0 || has(self.passwordSecretRef)",message="passwordSecretRef is required when keystores are set"
+type SecretSpec struct {
+	// name of the Secret holding the identity and of the Certificate that
+	// issues it. Defaults to <claim name>-identity. Immutable.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="secret.name is immutable"
+	Name string `json:"name,omitempty"`
+
+	// labels to add to the Secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// annotations to add to the Secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// keystores lists keystore formats to write alongside the PEM files.
+	// +optional
+	// +listType=set
+	Keystores []KeystoreType `json:"keystores,omitempty"`
+
+	// passwordSecretRef references the password used to encrypt the keystores.
+	// +optional
+	PasswordSecretRef *SecretKeySelector `json:"passwordSecretRef,omitempty"`
+
+	// additionalOutputFormats lists extra encodings of the key and certificate.
+	// +optional
+	// +listType=set
+	AdditionalOutputFormats []OutputFormat `json:"additionalOutputFormats,omitempty"`
+}
+
+// SPIFFESpec configures the SPIFFE ID of the claim.
+type SPIFFESpec struct {
+	// path is a Go template for the path of the SPIFFE ID, overriding the
+	// operator's --spiffe-id-path-template. Available fields are .Namespace, .Name,
+	// .ServiceAccount and .Labels; pod attributes are only set when every selected
+	// pod agrees on them. Example: ns/{{.Namespace}}/sa/{{.ServiceAccount}}
+	// +optional
+	// +kubebuilder:validation:MaxLength=512
+	Path string `json:"path,omitempty"`
+}
+
+// DeletionPolicy decides what happens to the Certificate and the identity
+// Secret when their IdentityClaim is deleted.
+// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Certificate and the Secret
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain deletes the Certificate but keeps the Secret, which
+	// stays usable until the certificate in it expires
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan keeps the Certificate and the Secret, so cert-manager
+	// keeps renewing the certificate without the claim
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// IdentityClaimSpec defines the desired state of IdentityClaim
+// +kubebuilder:validation:XValidation:rule="(has(self.secret) && has(self.secret.name)) == (has(oldSelf.secret) && has(oldSelf.secret.name))",message="secret.name is immutable"
+type IdentityClaimSpec struct {
+	// selector specifies which pods should receive the identity.
+	// Pods matching these labels will have access to the generated TLS certificate.
+	// +required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// certificate configures the lifetime, issuer, SANs and key of the certificate.
+	// +optional
+	// +kubebuilder:default={}
+	Certificate CertificateSpec `json:"certificate,omitzero"`
+
+	// secret configures the name, metadata and output formats of the identity Secret.
+	// +optional
+	Secret SecretSpec `json:"secret,omitzero"`
+
+	// spiffe configures the SPIFFE ID of the identity.
+	// +optional
+	SPIFFE SPIFFESpec `json:"spiffe,omitzero"`
+
+	// deletionPolicy decides what happens to the Certificate and the identity
+	// Secret when the claim is deleted: Delete removes both, Retain keeps the
+	// Secret, and Orphan keeps both and lets cert-manager keep renewing.
+	// +optional
+	// +kubebuilder:default="Delete"
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// deletionGracePeriod is how long a deleted claim keeps its Certificate and
+	// Secret while selected pods are still running, so pods can be rolled out
+	// before they lose their identity. Not used with deletionPolicy Orphan.
+	// +optional
+	// +kubebuilder:validation:Format=duration
+	DeletionGracePeriod *metav1.Duration `json:"deletionGracePeriod,omitempty"`
+
+	// suspend stops the operator from reconciling the claim, leaving its
+	// Certificate, Secret and status as they are until it is unset.
+	// cert-manager still renews the Certificate when it is due. Deleting a
+	// suspended claim still applies its deletionPolicy.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// IdentityClaimPhase represents the current phase of the IdentityClaim
+// +kubebuilder:validation:Enum=Pending;Issuing;Ready;Failed
+type IdentityClaimPhase string
+
+const (
+	// PhasePending means the claim is waiting for processing
+	PhasePending IdentityClaimPhase = "Pending"
+	// PhaseIssuing means the certificate is being issued
+	PhaseIssuing IdentityClaimPhase = "Issuing"
+	// PhaseReady means the identity is ready for use
+	PhaseReady IdentityClaimPhase = "Ready"
+	// PhaseFailed means the identity could not be issued
+	PhaseFailed IdentityClaimPhase = "Failed"
+)
+
+// IdentityClaimStatus defines the observed state of IdentityClaim.
+type IdentityClaimStatus struct {
+	// phase represents the current lifecycle phase of the identity claim.
+	// +optional
+	Phase IdentityClaimPhase `json:"phase,omitempty"`
+
+	// spiffeId is the SPIFFE identity URI assigned to this claim.
+	// Format: spiffe://<trust-domain>/<path>, where the path defaults to ns/<namespace>/ic/<name>
+	// +optional
+	SpiffeID string `json:"spiffeId,omitempty"`
+
+	// trustDomain is the SPIFFE trust domain the identity was issued under.
+	// +optional
+	TrustDomain string `json:"trustDomain,omitempty"`
+
+	// secretName is the name of the Secret containing the TLS certificate.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// secretKeys lists the data keys written to the Secret. ca.crt and the
+	// truststore entries are only present when the issuer returns a CA certificate.
+	// +optional
+	// +listType=atomic
+	SecretKeys []string `json:"secretKeys,omitempty"`
+
+	// expiresAt is the timestamp when the current certificate expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// deletionDeadline is when a deleted claim stops waiting for its selected
+	// pods and removes its Certificate and Secret as its deletionPolicy says.
+	// +optional
+	DeletionDeadline *metav1.Time `json:"deletionDeadline,omitempty"`
+
+	// lastRotationTime is when the certificate was last re-issued on request
+	// of the rotate-requested-at annotation.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// lastRotationReason is the rotate-reason annotation of the last rotation.
+	// +optional
+	LastRotationReason string `json:"lastRotationReason,omitempty"`
+
+	// lastRotationRequest is the rotate-requested-at annotation of the last
+	// rotation. Requests with the same or an earlier timestamp are ignored.
+	// +optional
+	LastRotationRequest string `json:"lastRotationRequest,omitempty"`
+
+	// conditions represent the current state of the IdentityClaim resource.
+	// Condition types: Ready, CertificateIssued, PodsVerified, SelectorConflict, PolicyViolation, QuotaExceeded, Suspended
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Current phase"
+// +kubebuilder:printcolumn:name="SPIFFE ID",type="string",JSONPath=".status.spiffeId",description="Assigned SPIFFE identity"
+// +kubebuilder:printcolumn:name="Secret",type="string",JSONPath=".status.secretName",description="Secret name"
+// +kubebuilder:printcolumn:name="Expires",type="date",JSONPath=".status.expiresAt",description="Certificate expiration"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// IdentityClaim is the Schema for the identityclaims API
+type IdentityClaim struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of IdentityClaim
+	// +required
+	Spec IdentityClaimSpec `json:"spec"`
+
+	// status defines the observed state of IdentityClaim
+	// +optional
+	Status IdentityClaimStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// IdentityClaimList contains a list of IdentityClaim
+type IdentityClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []IdentityClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IdentityClaim{}, &IdentityClaimList{})
+}
@@ -97,3 +97,38 @@ Create metrics reader cluster role name
 {{- define "identity-claim-operator.metricsReaderClusterRoleName" -}}
 {{- printf "%s-metrics-reader" (include "identity-claim-operator.fullname" .) }}
 {{- end }}
+
+{{/*
+Agent labels. The agent's pods must not match the operator's selector labels.
+*/}}
+{{- define "identity-claim-operator.agentLabels" -}}
+helm.sh/chart: {{ include "identity-claim-operator.chart" . }}
+{{ include "identity-claim-operator.agentSelectorLabels" . }}
+{{- if .Chart.AppVersion }}
+app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
+{{- end }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+{{- end }}
+
+{{/*
+Agent selector labels
+*/}}
+{{- define "identity-claim-operator.agentSelectorLabels" -}}
+app.kubernetes.io/name: {{ include "identity-claim-operator.name" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+app.kubernetes.io/component: agent
+{{- end }}
+
+{{/*
+Create agent name, used for its DaemonSet, service account and cluster role
+*/}}
+{{- define "identity-claim-operator.agentName" -}}
+{{- printf "%s-agent" (include "identity-claim-operator.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{/*
+Name of the agent's CSI driver, fixed in the agent
+*/}}
+{{- define "identity-claim-operator.csiDriverName" -}}
+csi.identity.cluster.local
+{{- end }}
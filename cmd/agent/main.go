@@ -0,0 +1,188 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command agent runs on every node and serves the identities of IdentityClaims
+// to the pods of the node over the SPIFFE Workload API and, optionally, Envoy's
+// Secret Discovery Service and a CSI driver for inline volumes.
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	// to ensure that exec-entrypoint and run can make use of them.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	secretv3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+	"github.com/osagberg/identity-claim-operator/internal/agent"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(identityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(certmanagerv1.AddToScheme(scheme))
+}
+
+func main() {
+	var metricsAddr string
+	var probeAddr string
+	var nodeName string
+	var socketPath string
+	var procPath string
+	var enableSDS bool
+	var csiSocketPath string
+	var csiStateDir string
+	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
+		"Use :8080 for HTTP, or leave as 0 to disable the metrics service.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&nodeName, "node-name", os.Getenv("NODE_NAME"),
+		"Name of the node the agent runs on. Only pods of this node are served.")
+	flag.StringVar(&socketPath, "socket-path", "/run/spiffe/workload.sock",
+		"Path of the Unix socket the SPIFFE Workload API is served on.")
+	flag.StringVar(&procPath, "proc-path", "/proc",
+		"Where the host's procfs is mounted. The agent must share the host's PID namespace.")
+	flag.BoolVar(&enableSDS, "enable-sds", false,
+		"If set, Envoy's Secret Discovery Service is served on the socket as well.")
+	flag.StringVar(&csiSocketPath, "csi-socket-path", "",
+		"If set, the CSI driver "+agent.CSIDriverName+" is served to the kubelet on this Unix socket.")
+	flag.StringVar(&csiStateDir, "csi-state-dir", "",
+		"Directory the published CSI volumes are recorded in. Defaults to volumes/ next to the CSI socket.")
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if nodeName == "" {
+		setupLog.Error(nil, "--node-name or the NODE_NAME environment variable must be set")
+		os.Exit(1)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
+		HealthProbeBindAddress: probeAddr,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Pod{}: {Field: fields.OneTermEqualSelector("spec.nodeName", nodeName)},
+			},
+		},
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	notifier := agent.NewNotifier()
+	if err := (&agent.ChangeReconciler{Notifier: notifier}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "agent")
+		os.Exit(1)
+	}
+	attestor := agent.CgroupAttestor{ProcPath: procPath}
+	identities := &agent.Identities{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+	}
+	if err := mgr.Add(&agent.Server{
+		Path: socketPath,
+		Register: func(s grpc.ServiceRegistrar) {
+			workload.RegisterSpiffeWorkloadAPIServer(s, &agent.WorkloadAPI{
+				Attestor:   attestor,
+				Identities: identities,
+				Notifier:   notifier,
+			})
+			if enableSDS {
+				secretv3.RegisterSecretDiscoveryServiceServer(s, &agent.SDS{
+					Attestor:   attestor,
+					Identities: identities,
+					Notifier:   notifier,
+				})
+			}
+		},
+	}); err != nil {
+		setupLog.Error(err, "unable to add Workload API server")
+		os.Exit(1)
+	}
+
+	if csiSocketPath != "" {
+		if csiStateDir == "" {
+			csiStateDir = filepath.Join(filepath.Dir(csiSocketPath), "volumes")
+		}
+		driver := &agent.CSIDriver{
+			NodeName:   nodeName,
+			Identities: identities,
+			Client:     mgr.GetClient(),
+			Reader:     mgr.GetAPIReader(),
+			Mounter:    agent.TmpfsMounter{},
+			StateDir:   csiStateDir,
+		}
+		if err := mgr.Add(driver); err != nil {
+			setupLog.Error(err, "unable to add CSI driver")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&agent.Server{
+			Path: csiSocketPath,
+			Register: func(s grpc.ServiceRegistrar) {
+				csi.RegisterIdentityServer(s, driver)
+				csi.RegisterNodeServer(s, driver)
+			},
+		}); err != nil {
+			setupLog.Error(err, "unable to add CSI server")
+			os.Exit(1)
+		}
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+
+	setupLog.Info("starting agent", "node", nodeName)
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running agent")
+		os.Exit(1)
+	}
+}
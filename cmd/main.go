@@ -19,7 +19,10 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"math"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -27,6 +30,7 @@ import (
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -37,7 +41,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+	identityv1beta1 "github.com/osagberg/identity-claim-operator/api/v1beta1"
 	"github.com/osagberg/identity-claim-operator/internal/controller"
+	"github.com/osagberg/identity-claim-operator/internal/jwtsvid"
+	"github.com/osagberg/identity-claim-operator/internal/spiffeid"
+	webhookv1 "github.com/osagberg/identity-claim-operator/internal/webhook/v1"
+	webhookv1alpha1 "github.com/osagberg/identity-claim-operator/internal/webhook/v1alpha1"
+	webhookv1beta1 "github.com/osagberg/identity-claim-operator/internal/webhook/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -51,6 +61,7 @@ func init() {
 	utilruntime.Must(certmanagerv1.AddToScheme(scheme))
 
 	utilruntime.Must(identityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(identityv1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -85,6 +96,66 @@ func main() {
 		"Default cert-manager issuer name when not specified in the IdentityClaim spec.")
 	flag.StringVar(&defaultIssuerKind, "default-issuer-kind", "ClusterIssuer",
 		"Default cert-manager issuer kind (Issuer or ClusterIssuer).")
+	var trustDomain string
+	flag.StringVar(&trustDomain, "trust-domain", spiffeid.DefaultTrustDomain,
+		"SPIFFE trust domain of issued identities, e.g. prod.example.org.")
+	var clusterDomain string
+	flag.StringVar(&clusterDomain, "cluster-domain", "cluster.local",
+		"DNS suffix of Services, used for DNS names derived with spec.dnsNames.fromServices.")
+	var spiffeIDPathTemplate string
+	flag.StringVar(&spiffeIDPathTemplate, "spiffe-id-path-template", spiffeid.DefaultPathTemplate,
+		"Go template for SPIFFE ID paths of claims without spec.spiffeIDPath. "+
+			"Fields: .Namespace, .Name, .ServiceAccount, .Labels.")
+	var allowedIssuerGroups string
+	flag.StringVar(&allowedIssuerGroups, "allowed-issuer-groups", "",
+		"Comma-separated API groups of external issuers that IdentityClaims may reference "+
+			"besides cert-manager.io, e.g. awspca.cert-manager.io.")
+	var identityMountPath string
+	flag.StringVar(&identityMountPath, "identity-mount-path", webhookv1.DefaultMountPath,
+		"Directory the identity Secret is mounted at in pods that opt into injection.")
+	var refuseOverlappingClaims bool
+	flag.BoolVar(&refuseOverlappingClaims, "refuse-overlapping-claims", false,
+		"If set, an IdentityClaim that selects the same pods as an older claim in its namespace is not issued.")
+	var maxClaimsPerNamespace int
+	flag.IntVar(&maxClaimsPerNamespace, "max-claims-per-namespace", 0,
+		"Default number of IdentityClaims a namespace may have. 0 means unlimited.")
+	var maxIssuancesPerHour int
+	flag.IntVar(&maxIssuancesPerHour, "max-issuances-per-hour", 0,
+		"Default number of certificates that may be issued in a namespace per hour. 0 means unlimited.")
+	var jwtSVIDAddr, jwtSVIDCertPath, jwtSVIDKeySecret, jwtSVIDAudience string
+	var jwtSVIDTTL, jwtSVIDKeyRotationPeriod time.Duration
+	flag.StringVar(&jwtSVIDAddr, "jwt-svid-bind-address", "0",
+		"The address the JWT-SVID token and key endpoints bind to. Leave as 0 to disable JWT-SVID issuance.")
+	flag.StringVar(&jwtSVIDCertPath, "jwt-svid-cert-path", "",
+		"The directory that contains the tls.crt and tls.key of the JWT-SVID endpoints. They are served over "+
+			"plain HTTP if empty.")
+	flag.StringVar(&jwtSVIDKeySecret, "jwt-svid-key-secret", "identity-claim-operator-system/identity-claim-operator-jwt-svid-keys",
+		"The namespace/name of the Secret the JWT-SVID signing keys are kept in.")
+	flag.StringVar(&jwtSVIDAudience, "jwt-svid-token-audience", jwtsvid.DefaultAudience,
+		"The audience ServiceAccount tokens presented to the token endpoint must be bound to.")
+	flag.DurationVar(&jwtSVIDTTL, "jwt-svid-ttl", jwtsvid.DefaultTTL, "The lifetime of issued JWT-SVIDs.")
+	flag.DurationVar(&jwtSVIDKeyRotationPeriod, "jwt-svid-key-rotation-period", jwtsvid.DefaultRotationPeriod,
+		"How long a JWT-SVID signing key is used before it is replaced. Must be longer than --jwt-svid-ttl plus one minute, "+
+			"for which a new key is published before it signs.")
+	var trustBundleName, trustBundleState, clusterResourceNamespace string
+	var trustBundleOverlap time.Duration
+	flag.StringVar(&trustBundleName, "trust-bundle-configmap", "",
+		"Name of the ConfigMap the CA certificates of all issuers referenced by IdentityClaims are written to "+
+			"in every namespace with claims. Leave empty to disable trust bundle distribution.")
+	flag.DurationVar(&trustBundleOverlap, "trust-bundle-overlap", controller.DefaultTrustBundleOverlap,
+		"How long a CA certificate stays in the trust bundle after its issuer stopped using it.")
+	flag.StringVar(&trustBundleState, "trust-bundle-state", "",
+		"The namespace/name of the ConfigMap, in the operator's namespace, recording the CA certificates of the "+
+			"trust bundle and when they were last used. Required with --trust-bundle-configmap.")
+	flag.StringVar(&clusterResourceNamespace, "cluster-resource-namespace", "cert-manager",
+		"The namespace cert-manager reads the CA Secrets of ClusterIssuers from.")
+	var agentServiceAccount string
+	var agentCSI bool
+	flag.StringVar(&agentServiceAccount, "agent-service-account", "",
+		"The namespace/name of the node agent's ServiceAccount, granted the identity Secrets in every namespace "+
+			"with IdentityClaims. Leave empty if the agent isn't deployed.")
+	flag.BoolVar(&agentCSI, "agent-csi", false,
+		"Also grant the node agent the CertificateRequests of its CSI volumes in namespaces with IdentityClaims.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	opts := zap.Options{
@@ -95,6 +166,44 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := spiffeid.ValidateTrustDomain(trustDomain); err != nil {
+		setupLog.Error(err, "invalid --trust-domain")
+		os.Exit(1)
+	}
+	if _, err := spiffeid.ParseTemplate(spiffeIDPathTemplate); err != nil {
+		setupLog.Error(err, "invalid --spiffe-id-path-template")
+		os.Exit(1)
+	}
+	if maxClaimsPerNamespace < 0 || maxClaimsPerNamespace > math.MaxInt32 ||
+		maxIssuancesPerHour < 0 || maxIssuancesPerHour > math.MaxInt32 {
+		setupLog.Error(nil, "--max-claims-per-namespace and --max-issuances-per-hour must be between 0 and 2147483647")
+		os.Exit(1)
+	}
+
+	keySecretNamespace, keySecretName, ok := strings.Cut(jwtSVIDKeySecret, "/")
+	if jwtSVIDAddr != "0" && (!ok || keySecretNamespace == "" || keySecretName == "") {
+		setupLog.Error(nil, "--jwt-svid-key-secret must be a namespace/name")
+		os.Exit(1)
+	}
+	if jwtSVIDTTL <= 0 || jwtSVIDKeyRotationPeriod <= jwtSVIDTTL+jwtsvid.PrePublishDelay {
+		setupLog.Error(nil, "--jwt-svid-ttl must be positive and shorter than --jwt-svid-key-rotation-period minus one minute")
+		os.Exit(1)
+	}
+	if trustBundleOverlap < 0 {
+		setupLog.Error(nil, "--trust-bundle-overlap must not be negative")
+		os.Exit(1)
+	}
+	stateNamespace, stateName, ok := strings.Cut(trustBundleState, "/")
+	if trustBundleName != "" && (!ok || stateNamespace == "" || stateName == "") {
+		setupLog.Error(nil, "--trust-bundle-state must be a namespace/name")
+		os.Exit(1)
+	}
+	agentNamespace, agentName, ok := strings.Cut(agentServiceAccount, "/")
+	if agentServiceAccount != "" && (!ok || agentNamespace == "" || agentName == "") {
+		setupLog.Error(nil, "--agent-service-account must be a namespace/name")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -187,16 +296,104 @@ func main() {
 	}
 
 	if err := (&controller.IdentityClaimReconciler{
-		Client:            mgr.GetClient(),
-		Scheme:            mgr.GetScheme(),
-		DefaultIssuerName: defaultIssuerName,
-		DefaultIssuerKind: defaultIssuerKind,
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		DefaultIssuerName:       defaultIssuerName,
+		DefaultIssuerKind:       defaultIssuerKind,
+		TrustDomain:             trustDomain,
+		SpiffeIDPathTemplate:    spiffeIDPathTemplate,
+		ClusterDomain:           clusterDomain,
+		RefuseOverlappingClaims: refuseOverlappingClaims,
+		MaxClaimsPerNamespace:   int32(maxClaimsPerNamespace),
+		MaxIssuancesPerHour:     int32(maxIssuancesPerHour),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "IdentityClaim")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupIdentityClaimWebhookWithManager(mgr, splitList(allowedIssuerGroups)); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IdentityClaim")
+			os.Exit(1)
+		}
+		if err := webhookv1.SetupPodWebhookWithManager(mgr, identityMountPath); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+		if err := webhookv1beta1.SetupIdentityClaimWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IdentityClaim")
+			os.Exit(1)
+		}
+	}
+	if err := (&controller.IssuanceReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Issuance")
+		os.Exit(1)
+	}
+	if err := (&controller.ClusterIdentityClaimReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterIdentityClaim")
+		os.Exit(1)
+	}
+	if trustBundleName != "" {
+		if err := (&controller.TrustBundleReconciler{
+			Client:                   mgr.GetClient(),
+			Name:                     trustBundleName,
+			State:                    types.NamespacedName{Namespace: stateNamespace, Name: stateName},
+			DefaultIssuerName:        defaultIssuerName,
+			DefaultIssuerKind:        defaultIssuerKind,
+			ClusterResourceNamespace: clusterResourceNamespace,
+			Overlap:                  trustBundleOverlap,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "TrustBundle")
+			os.Exit(1)
+		}
+	}
+	if agentServiceAccount != "" {
+		if err := (&controller.AgentAccessReconciler{
+			Client:         mgr.GetClient(),
+			ServiceAccount: types.NamespacedName{Namespace: agentNamespace, Name: agentName},
+			CSI:            agentCSI,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AgentAccess")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if jwtSVIDAddr != "0" {
+		keys := &jwtsvid.KeyStore{
+			Client:         mgr.GetClient(),
+			Reader:         mgr.GetAPIReader(),
+			Secret:         types.NamespacedName{Namespace: keySecretNamespace, Name: keySecretName},
+			RotationPeriod: jwtSVIDKeyRotationPeriod,
+		}
+		if err := mgr.Add(keys); err != nil {
+			setupLog.Error(err, "unable to add JWT-SVID key rotation")
+			os.Exit(1)
+		}
+		issuer := &jwtsvid.Issuer{
+			Client:   mgr.GetClient(),
+			Keys:     keys,
+			Audience: jwtSVIDAudience,
+			TTL:      jwtSVIDTTL,
+		}
+		if err := mgr.Add(&jwtsvid.Server{
+			BindAddress: jwtSVIDAddr,
+			CertDir:     jwtSVIDCertPath,
+			CertName:    "tls.crt",
+			KeyName:     "tls.key",
+			TLSOpts:     tlsOpts,
+			Handler:     issuer.Handler(),
+		}); err != nil {
+			setupLog.Error(err, "unable to add JWT-SVID server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -212,3 +409,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package agent implements the node-local agent that serves the identities of
+// IdentityClaims to the pods running on its node.
+package agent
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podUIDPattern matches the pod segment of a cgroup path. The cgroupfs driver
+// names it pod<uid>, the systemd driver kubepods-<qos>-pod<uid>.slice with the
+// dashes of the UID replaced by underscores.
+var podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+// Attestor identifies the pod a process runs in.
+type Attestor interface {
+	// PodUID returns the UID of the pod running the process pid.
+	PodUID(pid int32) (types.UID, error)
+}
+
+// CgroupAttestor reads the pod of a process from its cgroup path, which the
+// kubelet names after the pod UID on both cgroup drivers and cgroup versions.
+// It needs the host's PID namespace, so the PIDs of callers are meaningful.
+type CgroupAttestor struct {
+	// ProcPath is where procfs is mounted.
+	ProcPath string
+}
+
+// PodUID implements Attestor.
+func (a CgroupAttestor) PodUID(pid int32) (types.UID, error) {
+	f, err := os.Open(filepath.Join(a.ProcPath, strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read cgroups of process %d: %w", pid, err)
+	}
+	defer func() { _ = f.Close() }()
+	uid, err := podUIDFromCgroups(f)
+	if err != nil {
+		return "", fmt.Errorf("process %d: %w", pid, err)
+	}
+	return uid, nil
+}
+
+// podUIDFromCgroups returns the pod UID in the cgroup paths of a
+// /proc/<pid>/cgroup file. Every hierarchy must agree on it.
+func podUIDFromCgroups(r io.Reader) (types.UID, error) {
+	var uid string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		match := podUIDPattern.FindStringSubmatch(parts[2])
+		if match == nil {
+			continue
+		}
+		found := strings.ReplaceAll(match[1], "_", "-")
+		if uid != "" && uid != found {
+			return "", fmt.Errorf("cgroups name different pods %s and %s", uid, found)
+		}
+		uid = found
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if uid == "" {
+		return "", errors.New("not running in a pod")
+	}
+	return types.UID(uid), nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("podUIDFromCgroups", func() {
+	const uid = types.UID("3e61c214-bc3e-11e9-87f2-0a58ac1e0074")
+
+	DescribeTable("should find the pod UID",
+		func(cgroups string) {
+			Expect(podUIDFromCgroups(strings.NewReader(cgroups))).To(Equal(uid))
+		},
+		Entry("with the cgroupfs driver", ""+
+			"12:memory:/kubepods/burstable/pod3e61c214-bc3e-11e9-87f2-0a58ac1e0074/0f0e4b9b7c\n"+
+			"11:cpu,cpuacct:/kubepods/burstable/pod3e61c214-bc3e-11e9-87f2-0a58ac1e0074/0f0e4b9b7c\n"+
+			"1:name=systemd:/kubepods/burstable/pod3e61c214-bc3e-11e9-87f2-0a58ac1e0074/0f0e4b9b7c\n"),
+		Entry("with the systemd driver on cgroup v2",
+			"0::/kubepods.slice/kubepods-besteffort.slice/"+
+				"kubepods-besteffort-pod3e61c214_bc3e_11e9_87f2_0a58ac1e0074.slice/cri-containerd-0f0e4b9b7c.scope\n"),
+		Entry("outside of the agent's cgroup namespace",
+			"0::/../../kubepods-pod3e61c214_bc3e_11e9_87f2_0a58ac1e0074.slice/cri-containerd-0f0e4b9b7c.scope\n"),
+	)
+
+	It("should reject processes outside of pods", func() {
+		_, err := podUIDFromCgroups(strings.NewReader("0::/system.slice/containerd.service\n"))
+		Expect(err).To(MatchError(ContainSubstring("not running in a pod")))
+	})
+
+	It("should reject cgroups naming different pods", func() {
+		_, err := podUIDFromCgroups(strings.NewReader("" +
+			"2:memory:/kubepods/pod3e61c214-bc3e-11e9-87f2-0a58ac1e0074/0f0e4b9b7c\n" +
+			"1:cpu:/kubepods/pod5a8b7c9d-0000-11e9-87f2-0a58ac1e0074/0f0e4b9b7c\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
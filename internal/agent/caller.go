@@ -0,0 +1,70 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// attestCaller returns the UID of the pod whose process opened the
+// connection of a request.
+func attestCaller(ctx context.Context, attestor Attestor) (types.UID, error) {
+	pid, ok := callerPID(ctx)
+	if !ok {
+		return "", status.Error(codes.Internal, "caller's process is unknown")
+	}
+	uid, err := attestor.PodUID(pid)
+	if err != nil {
+		return "", status.Errorf(codes.PermissionDenied, "failed to attest caller: %v", err)
+	}
+	return uid, nil
+}
+
+// callerPod returns the attested pod with the UID.
+func callerPod(ctx context.Context, identities *Identities, uid types.UID) (*corev1.Pod, error) {
+	pod, err := identities.Pod(ctx, uid)
+	if errors.Is(err, ErrPodNotFound) {
+		return nil, status.Errorf(codes.PermissionDenied, "pod %s is not running on this node", uid)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return pod, nil
+}
+
+// callerIdentities returns the attested pod with the UID and its identities.
+// A pod without identities is denied.
+func callerIdentities(ctx context.Context, identities *Identities, uid types.UID) (*corev1.Pod, []X509Identity, error) {
+	pod, err := callerPod(ctx, identities, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	found, err := identities.X509(ctx, pod)
+	if err != nil {
+		return nil, nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if len(found) == 0 {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "no identity has been issued for pod %s/%s", pod.Namespace, pod.Name)
+	}
+	return pod, found, nil
+}
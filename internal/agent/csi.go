@@ -0,0 +1,350 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// CSIDriverName is the name the CSI driver registers with the kubelet, which
+// pods reference in their csi volumes.
+const CSIDriverName = "csi.identity.cluster.local"
+
+// Volume attributes pods may set on their csi volumes.
+const (
+	// ClaimAttribute names the IdentityClaim to issue for when several select
+	// the pod. The oldest one is used otherwise.
+	ClaimAttribute = "identity.cluster.local/claim"
+	// FSGroupAttribute makes the files owned by the group, usually the pod's
+	// fsGroup, and readable only by it. They are readable by everyone otherwise.
+	FSGroupAttribute = "identity.cluster.local/fs-group"
+)
+
+// Volume attributes the kubelet adds for drivers with podInfoOnMount.
+const (
+	ephemeralAttribute    = "csi.storage.k8s.io/ephemeral"
+	podNameAttribute      = "csi.storage.k8s.io/pod.name"
+	podNamespaceAttribute = "csi.storage.k8s.io/pod.namespace"
+	podUIDAttribute       = "csi.storage.k8s.io/pod.uid"
+)
+
+const (
+	// DefaultCSIResync is how often volumes are checked for renewal by default.
+	DefaultCSIResync = time.Minute
+	// DefaultPollInterval is how often CertificateRequests are checked by default.
+	DefaultPollInterval = time.Second
+	// renewTimeout bounds how long a renewal waits for its certificate.
+	renewTimeout = 2 * time.Minute
+)
+
+// CSIDriver is a CSI node plugin for ephemeral inline volumes holding an
+// identity of their pod. Unlike the claim's Secret, each volume has its own
+// private key, generated by the agent and only ever written to the volume's
+// tmpfs. Its certificate is requested with a cert-manager CertificateRequest
+// built from the claim's Certificate and renewed before it expires.
+type CSIDriver struct {
+	csi.UnimplementedIdentityServer
+	csi.UnimplementedNodeServer
+
+	// NodeName is reported to the kubelet as the node ID.
+	NodeName string
+	// Identities finds the pods of the node and the claims selecting them.
+	Identities *Identities
+	// Client creates and deletes CertificateRequests.
+	Client client.Client
+	// Reader reads Certificates and CertificateRequests, which the agent
+	// doesn't cache.
+	Reader client.Reader
+	// Mounter mounts the tmpfs of each volume.
+	Mounter Mounter
+	// StateDir records the published volumes, so renewals resume after the
+	// agent restarts.
+	StateDir string
+	// Resync is how often volumes are checked for renewal, DefaultCSIResync
+	// if zero.
+	Resync time.Duration
+	// PollInterval is how often a pending CertificateRequest is checked,
+	// DefaultPollInterval if zero.
+	PollInterval time.Duration
+
+	mu      sync.Mutex
+	volumes map[string]*csiVolume
+}
+
+// GetPluginInfo implements csi.IdentityServer.
+func (d *CSIDriver) GetPluginInfo(context.Context, *csi.GetPluginInfoRequest) (*csi.GetPluginInfoResponse, error) {
+	return &csi.GetPluginInfoResponse{Name: CSIDriverName}, nil
+}
+
+// GetPluginCapabilities implements csi.IdentityServer. Without the controller
+// service, the driver has no plugin capabilities.
+func (d *CSIDriver) GetPluginCapabilities(context.Context, *csi.GetPluginCapabilitiesRequest) (*csi.GetPluginCapabilitiesResponse, error) {
+	return &csi.GetPluginCapabilitiesResponse{}, nil
+}
+
+// Probe implements csi.IdentityServer.
+func (d *CSIDriver) Probe(context.Context, *csi.ProbeRequest) (*csi.ProbeResponse, error) {
+	return &csi.ProbeResponse{}, nil
+}
+
+// NodeGetCapabilities implements csi.NodeServer. Volumes are neither staged
+// nor expanded.
+func (d *CSIDriver) NodeGetCapabilities(context.Context, *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	return &csi.NodeGetCapabilitiesResponse{}, nil
+}
+
+// NodeGetInfo implements csi.NodeServer.
+func (d *CSIDriver) NodeGetInfo(context.Context, *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
+	return &csi.NodeGetInfoResponse{NodeId: d.NodeName}, nil
+}
+
+// NodePublishVolume implements csi.NodeServer. It mounts a tmpfs at the target
+// path and writes an identity issued for the claim selecting the pod to it.
+func (d *CSIDriver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+	log := logf.FromContext(ctx)
+
+	if req.GetVolumeId() == "" || req.GetTargetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume ID and target path are required")
+	}
+	if req.GetVolumeCapability().GetMount() == nil {
+		return nil, status.Error(codes.InvalidArgument, "only mount volumes are supported")
+	}
+	attrs := req.GetVolumeContext()
+	if attrs[ephemeralAttribute] != "true" {
+		return nil, status.Error(codes.InvalidArgument, "only ephemeral inline volumes are supported")
+	}
+
+	d.mu.Lock()
+	published := d.volumes[req.GetVolumeId()]
+	d.mu.Unlock()
+	if published != nil {
+		if published.TargetPath != req.GetTargetPath() {
+			return nil, status.Errorf(codes.AlreadyExists, "volume %s is published at %s", published.ID, published.TargetPath)
+		}
+		return &csi.NodePublishVolumeResponse{}, nil
+	}
+
+	vol := &csiVolume{
+		ID:         req.GetVolumeId(),
+		TargetPath: req.GetTargetPath(),
+		Namespace:  attrs[podNamespaceAttribute],
+		PodName:    attrs[podNameAttribute],
+		PodUID:     types.UID(attrs[podUIDAttribute]),
+	}
+	if group, ok := attrs[FSGroupAttribute]; ok {
+		gid, err := strconv.ParseInt(group, 10, 64)
+		if err != nil || gid < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "%s must be a group ID, got %q", FSGroupAttribute, group)
+		}
+		vol.FSGroup = &gid
+	}
+
+	pod, err := callerPod(ctx, d.Identities, vol.PodUID)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Namespace != vol.Namespace || pod.Name != vol.PodName {
+		return nil, status.Errorf(codes.InvalidArgument, "pod %s is %s/%s, not %s/%s",
+			vol.PodUID, pod.Namespace, pod.Name, vol.Namespace, vol.PodName)
+	}
+	claim, err := d.claim(ctx, pod, attrs[ClaimAttribute])
+	if err != nil {
+		return nil, err
+	}
+	vol.Claim = claim.Name
+
+	if err := d.Mounter.Mount(vol.TargetPath); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := d.renew(ctx, vol, claim); err != nil {
+		if err := d.unmount(vol.TargetPath); err != nil {
+			log.Error(err, "Failed to clean up volume", "volume", vol.ID)
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	if d.volumes == nil {
+		d.volumes = map[string]*csiVolume{}
+	}
+	d.volumes[vol.ID] = vol
+	d.mu.Unlock()
+	log.Info("Published volume", "volume", vol.ID, "pod", client.ObjectKeyFromObject(pod), "claim", claim.Name)
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+// NodeUnpublishVolume implements csi.NodeServer. The volume's identity is
+// gone once its tmpfs is unmounted.
+func (d *CSIDriver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
+	if req.GetVolumeId() == "" || req.GetTargetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume ID and target path are required")
+	}
+
+	d.mu.Lock()
+	vol := d.volumes[req.GetVolumeId()]
+	delete(d.volumes, req.GetVolumeId())
+	d.mu.Unlock()
+	if vol != nil {
+		// Wait for a running renewal, and keep later ones from writing to
+		// the node's disk once the tmpfs is gone
+		vol.mu.Lock()
+		vol.unpublished = true
+		vol.mu.Unlock()
+		d.deleteRequest(ctx, vol.Namespace, vol.Request)
+	}
+
+	if err := d.unmount(req.GetTargetPath()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := d.forget(req.GetVolumeId()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	logf.FromContext(ctx).Info("Unpublished volume", "volume", req.GetVolumeId())
+	return &csi.NodeUnpublishVolumeResponse{}, nil
+}
+
+// claim returns the claim to issue the pod's volume for: the named one, or
+// the oldest selecting the pod. Only claims the agent would serve over the
+// Workload API can be issued, so failed, suspended and quota-limited claims
+// can't mint certificates through volumes either.
+func (d *CSIDriver) claim(ctx context.Context, pod *corev1.Pod, name string) (*identityv1alpha1.IdentityClaim, error) {
+	claims, err := d.Identities.Claims(ctx, pod)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	for i := range claims {
+		if claims[i].Status.SpiffeID != "" && (name == "" || claims[i].Name == name) {
+			return &claims[i], nil
+		}
+	}
+	if name != "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "IdentityClaim %s doesn't select pod %s/%s or isn't Ready",
+			name, pod.Namespace, pod.Name)
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "no Ready IdentityClaim selects pod %s/%s",
+		pod.Namespace, pod.Name)
+}
+
+// Start implements manager.Runnable. It resumes the volumes published before
+// the agent restarted and renews volumes until ctx is done.
+func (d *CSIDriver) Start(ctx context.Context) error {
+	if err := d.load(ctx); err != nil {
+		return err
+	}
+
+	resync := d.Resync
+	if resync == 0 {
+		resync = DefaultCSIResync
+	}
+	ticker := time.NewTicker(resync)
+	defer ticker.Stop()
+	for {
+		d.renewDue(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (d *CSIDriver) NeedLeaderElection() bool {
+	return false
+}
+
+// renewDue renews the volumes whose certificate is due for renewal, and those
+// whose claim changed its SPIFFE ID or was rotated since they were issued.
+// Failed renewals are retried on the next resync; the volume keeps its
+// certificate until then.
+func (d *CSIDriver) renewDue(ctx context.Context) {
+	log := logf.FromContext(ctx)
+
+	d.mu.Lock()
+	volumes := slices.Collect(maps.Values(d.volumes))
+	d.mu.Unlock()
+	for _, vol := range volumes {
+		if err := d.renewIfDue(ctx, vol); err != nil {
+			log.Error(err, "Failed to renew volume", "volume", vol.ID,
+				"pod", types.NamespacedName{Namespace: vol.Namespace, Name: vol.PodName}, "claim", vol.Claim)
+		}
+	}
+}
+
+// renewIfDue renews vol if it is due.
+func (d *CSIDriver) renewIfDue(ctx context.Context, vol *csiVolume) error {
+	vol.mu.Lock()
+	defer vol.mu.Unlock()
+	if vol.unpublished {
+		return nil
+	}
+
+	claim := &identityv1alpha1.IdentityClaim{}
+	err := d.Identities.Client.Get(ctx, client.ObjectKey{Namespace: vol.Namespace, Name: vol.Claim}, claim)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	due := !time.Now().Before(vol.RenewAt)
+	if err == nil {
+		rotated := claim.Status.LastRotationTime != nil && claim.Status.LastRotationTime.After(vol.IssuedAt)
+		due = due || rotated || (claim.Status.SpiffeID != "" && claim.Status.SpiffeID != vol.SpiffeID)
+	}
+	if !due {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("IdentityClaim %s no longer exists", vol.Claim)
+	}
+
+	pod, err := d.Identities.Pod(ctx, vol.PodUID)
+	if errors.Is(err, ErrPodNotFound) {
+		// Gone; the kubelet unpublishes the volume
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	claims, err := d.Identities.Claims(ctx, pod)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(claims, func(c identityv1alpha1.IdentityClaim) bool { return c.Name == claim.Name }) {
+		return fmt.Errorf("IdentityClaim %s no longer selects the pod or isn't Ready", vol.Claim)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renewTimeout)
+	defer cancel()
+	return d.renew(ctx, vol, claim)
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// dirMounter stands in for tmpfs mounts with plain directories.
+type dirMounter struct{}
+
+func (dirMounter) Mount(target string) error {
+	return os.MkdirAll(target, 0o750)
+}
+
+func (dirMounter) Unmount(target string) error {
+	// The files of a tmpfs are gone with it
+	return os.RemoveAll(target)
+}
+
+// sign issues the pending CertificateRequests like cert-manager with a CA
+// issuer until ctx is done, and sends each one it issued to issued.
+func (ca *testCA) sign(ctx context.Context, c client.Client, issued chan<- *certmanagerv1.CertificateRequest) {
+	defer GinkgoRecover()
+	serial := int64(10)
+	for ctx.Err() == nil {
+		crs := &certmanagerv1.CertificateRequestList{}
+		Expect(c.List(ctx, crs)).To(Succeed())
+		for i := range crs.Items {
+			cr := &crs.Items[i]
+			if len(cr.Status.Certificate) > 0 {
+				continue
+			}
+			block, _ := pem.Decode(cr.Spec.Request)
+			Expect(block).NotTo(BeNil())
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			serial++
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(serial),
+				Subject:      csr.Subject,
+				NotBefore:    time.Now().Add(-time.Minute),
+				NotAfter:     time.Now().Add(cr.Spec.Duration.Duration),
+				URIs:         csr.URIs,
+				DNSNames:     csr.DNSNames,
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+			Expect(err).NotTo(HaveOccurred())
+			cr.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+			cr.Status.CA = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+			cr.Status.Conditions = []certmanagerv1.CertificateRequestCondition{{
+				Type:   certmanagerv1.CertificateRequestConditionReady,
+				Status: cmmeta.ConditionTrue,
+				Reason: certmanagerv1.CertificateRequestReasonIssued,
+			}}
+			Expect(c.Update(ctx, cr)).To(Succeed())
+			issued <- cr.DeepCopy()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+const volumeID = "csi-5f7c2d3e9a"
+
+// publishRequest returns the request the kubelet makes for an inline volume
+// of the pod selected by the web claim.
+func publishRequest(target string) *csi.NodePublishVolumeRequest {
+	return &csi.NodePublishVolumeRequest{
+		VolumeId:   volumeID,
+		TargetPath: target,
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		},
+		VolumeContext: map[string]string{
+			ephemeralAttribute:    "true",
+			podNameAttribute:      "web-0",
+			podNamespaceAttribute: "default",
+			podUIDAttribute:       string(podUID),
+		},
+	}
+}
+
+var _ = Describe("CSIDriver", func() {
+	var (
+		ctx    context.Context
+		c      client.Client
+		ca     *testCA
+		issued chan *certmanagerv1.CertificateRequest
+		driver *CSIDriver
+		target string
+	)
+
+	// readSVID reads the identity the volume holds.
+	readSVID := func() *x509svid.SVID {
+		svid, err := x509svid.Load(filepath.Join(target, "tls.crt"), filepath.Join(target, "tls.key"))
+		Expect(err).NotTo(HaveOccurred())
+		return svid
+	}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(func() { cancel() })
+		ca = newTestCA()
+
+		c = newIssuedClient(ca)
+		issued = make(chan *certmanagerv1.CertificateRequest, 10)
+		go ca.sign(ctx, c, issued)
+
+		dir := GinkgoT().TempDir()
+		target = filepath.Join(dir, "pods", string(podUID), "volumes", "identity", "mount")
+		driver = &CSIDriver{
+			NodeName:     "node-a",
+			Identities:   &Identities{Client: c, APIReader: c},
+			Client:       c,
+			Reader:       c,
+			Mounter:      dirMounter{},
+			StateDir:     filepath.Join(dir, "state"),
+			PollInterval: 10 * time.Millisecond,
+		}
+	})
+
+	It("should write an identity with its own key to the volume", func() {
+		_, err := driver.NodePublishVolume(ctx, publishRequest(target))
+		Expect(err).NotTo(HaveOccurred())
+
+		svid := readSVID()
+		Expect(svid.ID.String()).To(Equal(spiffeID))
+		caPEM, err := os.ReadFile(filepath.Join(target, "ca.crt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(caPEM).To(ContainSubstring("CERTIFICATE"))
+
+		var cr *certmanagerv1.CertificateRequest
+		Expect(issued).To(Receive(&cr))
+		Expect(cr.Spec.IssuerRef.Name).To(Equal("ca-issuer"))
+		Expect(cr.Spec.Duration.Duration).To(Equal(time.Hour))
+		Expect(cr.Labels).To(HaveKeyWithValue(identityv1alpha1.ClaimLabel, "web"))
+		Expect(cr.OwnerReferences).To(ContainElement(HaveField("UID", podUID)))
+
+		// The request is kept to count toward the issuance rate, and the key was never sent
+		crs := &certmanagerv1.CertificateRequestList{}
+		Expect(c.List(ctx, crs)).To(Succeed())
+		Expect(crs.Items).To(ConsistOf(HaveField("Name", cr.Name)))
+		Expect(driver.volumes[volumeID].Request).To(Equal(cr.Name))
+		Expect(string(cr.Spec.Request)).NotTo(ContainSubstring("PRIVATE KEY"))
+
+		// Publishing again is a no-op
+		_, err = driver.NodePublishVolume(ctx, publishRequest(target))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issued).NotTo(Receive())
+	})
+
+	It("should renew the identity when it is due and when the claim is rotated", func() {
+		_, err := driver.NodePublishVolume(ctx, publishRequest(target))
+		Expect(err).NotTo(HaveOccurred())
+		first := readSVID()
+		vol := driver.volumes[volumeID]
+		Expect(vol.RenewAt).To(BeTemporally("~", first.Certificates[0].NotAfter.Add(-20*time.Minute), time.Second))
+
+		driver.renewDue(ctx)
+		Expect(readSVID().Certificates[0].SerialNumber).To(Equal(first.Certificates[0].SerialNumber))
+
+		vol.RenewAt = time.Now().Add(-time.Second)
+		driver.renewDue(ctx)
+		renewed := readSVID()
+		Expect(renewed.Certificates[0].SerialNumber).NotTo(Equal(first.Certificates[0].SerialNumber))
+		Expect(renewed.PrivateKey).NotTo(Equal(first.PrivateKey))
+
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, claim)).To(Succeed())
+		claim.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(time.Second)}
+		Expect(c.Update(ctx, claim)).To(Succeed())
+		driver.renewDue(ctx)
+		Expect(readSVID().Certificates[0].SerialNumber).NotTo(Equal(renewed.Certificates[0].SerialNumber))
+
+		// Only the request of the current certificate is kept
+		crs := &certmanagerv1.CertificateRequestList{}
+		Expect(c.List(ctx, crs)).To(Succeed())
+		Expect(crs.Items).To(ConsistOf(HaveField("Name", vol.Request)))
+
+		By("suspending the claim")
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, claim)).To(Succeed())
+		claim.Spec.Suspend = true
+		Expect(c.Update(ctx, claim)).To(Succeed())
+		vol.RenewAt = time.Now().Add(-time.Second)
+		Expect(driver.renewIfDue(ctx, vol)).To(MatchError(ContainSubstring("isn't Ready")))
+	})
+
+	It("should resume volumes after a restart and forget them when unpublished", func() {
+		_, err := driver.NodePublishVolume(ctx, publishRequest(target))
+		Expect(err).NotTo(HaveOccurred())
+
+		restarted := &CSIDriver{
+			Identities: driver.Identities,
+			Client:     c,
+			Reader:     c,
+			Mounter:    dirMounter{},
+			StateDir:   driver.StateDir,
+		}
+		Expect(restarted.load(ctx)).To(Succeed())
+		Expect(restarted.volumes).To(HaveKeyWithValue(volumeID, And(
+			HaveField("TargetPath", target),
+			HaveField("Claim", "web"),
+			HaveField("SpiffeID", spiffeID),
+		)))
+
+		_, err = restarted.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: volumeID, TargetPath: target})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).NotTo(BeADirectory())
+		Expect(restarted.statePath(volumeID)).NotTo(BeAnExistingFile())
+		crs := &certmanagerv1.CertificateRequestList{}
+		Expect(c.List(ctx, crs)).To(Succeed())
+		Expect(crs.Items).To(BeEmpty())
+	})
+
+	It("should refuse volumes it can't issue", func() {
+		req := publishRequest(target)
+		delete(req.VolumeContext, ephemeralAttribute)
+		_, err := driver.NodePublishVolume(ctx, req)
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		req = publishRequest(target)
+		req.VolumeContext[ClaimAttribute] = "api"
+		_, err = driver.NodePublishVolume(ctx, req)
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, claim)).To(Succeed())
+		claim.Status.Phase = identityv1alpha1.PhaseFailed
+		Expect(c.Update(ctx, claim)).To(Succeed())
+		_, err = driver.NodePublishVolume(ctx, publishRequest(target))
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+		req = publishRequest(target)
+		req.VolumeContext[podNameAttribute] = "web-1"
+		_, err = driver.NodePublishVolume(ctx, req)
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(target).NotTo(BeADirectory())
+	})
+})
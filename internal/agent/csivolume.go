@@ -0,0 +1,442 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// podUIDLabel is the label of the CertificateRequests of CSI volumes that
+// names their pod, next to identityv1alpha1.ClaimLabel.
+const podUIDLabel = "identity.cluster.local/pod-uid"
+
+// csiVolume is a published volume, as recorded in the state directory.
+type csiVolume struct {
+	// mu serializes renewals with unpublishing the volume.
+	mu sync.Mutex
+	// unpublished is set once the volume is being unmounted, after which
+	// nothing may be written to its target path.
+	unpublished bool
+
+	ID         string    `json:"id"`
+	TargetPath string    `json:"targetPath"`
+	Namespace  string    `json:"namespace"`
+	PodName    string    `json:"podName"`
+	PodUID     types.UID `json:"podUID"`
+	Claim      string    `json:"claim"`
+	FSGroup    *int64    `json:"fsGroup,omitempty"`
+	// SpiffeID is the claim's SPIFFE ID the certificate was issued for.
+	SpiffeID string    `json:"spiffeID"`
+	IssuedAt time.Time `json:"issuedAt"`
+	RenewAt  time.Time `json:"renewAt"`
+	// Request is the CertificateRequest the certificate was issued by. It is
+	// kept until the next one replaces it, so the operator counts it toward
+	// the namespace's issuance rate.
+	Request string `json:"request,omitempty"`
+}
+
+// renew issues a new identity for vol and writes it to the volume. The caller
+// holds vol.mu, or vol hasn't been published yet.
+func (d *CSIDriver) renew(ctx context.Context, vol *csiVolume, claim *identityv1alpha1.IdentityClaim) error {
+	cert := &certmanagerv1.Certificate{}
+	key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}
+	if err := d.Reader.Get(ctx, key, cert); err != nil {
+		if apierrors.IsNotFound(err) {
+			return status.Errorf(codes.FailedPrecondition, "IdentityClaim %s has no Certificate yet", claim.Name)
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	privateKey, err := generatePrivateKey(cert.Spec.PrivateKey)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	keyPEM, err := encodePrivateKey(privateKey, cert.Spec.PrivateKey)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	csrPEM, err := certificateSigningRequest(cert, privateKey)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	cr, err := d.requestCertificate(ctx, vol, claim, cert, csrPEM)
+	if err != nil {
+		return err
+	}
+	chain, err := parseCertificates(cr.Status.Certificate)
+	if err != nil {
+		return status.Errorf(codes.Internal, "CertificateRequest %s: %v", cr.Name, err)
+	}
+	leaf := chain[0]
+
+	files := []volumeFile{
+		{name: corev1.TLSPrivateKeyKey, data: keyPEM},
+		{name: corev1.TLSCertKey, data: cr.Status.Certificate},
+	}
+	if len(cr.Status.CA) > 0 {
+		files = append(files, volumeFile{name: cmmeta.TLSCAKey, data: cr.Status.CA})
+	}
+	if err := vol.write(files); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	previous := vol.Request
+	vol.SpiffeID = claim.Status.SpiffeID
+	vol.IssuedAt = time.Now()
+	vol.RenewAt = leaf.NotAfter.Add(-renewBefore(leaf, cert.Spec.RenewBefore))
+	vol.Request = cr.Name
+	if err := d.save(vol); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	d.deleteRequest(ctx, vol.Namespace, previous)
+	logf.FromContext(ctx).V(1).Info("Issued volume", "volume", vol.ID, "notAfter", leaf.NotAfter, "renewAt", vol.RenewAt)
+	return nil
+}
+
+// requestCertificate creates a CertificateRequest for the CSR and waits until
+// it has been issued. Requests that weren't issued are deleted; issued ones
+// are kept in vol.Request. Either way it is owned by the pod in case the
+// agent doesn't get to it.
+func (d *CSIDriver) requestCertificate(ctx context.Context, vol *csiVolume, claim *identityv1alpha1.IdentityClaim,
+	cert *certmanagerv1.Certificate, csrPEM []byte) (*certmanagerv1.CertificateRequest, error) {
+	cr := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: claim.Name + "-",
+			Namespace:    claim.Namespace,
+			Labels: map[string]string{
+				identityv1alpha1.ClaimLabel: claim.Name,
+				podUIDLabel:                 string(vol.PodUID),
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       vol.PodName,
+				UID:        vol.PodUID,
+			}},
+		},
+		Spec: certmanagerv1.CertificateRequestSpec{
+			Duration:  cert.Spec.Duration,
+			IssuerRef: cert.Spec.IssuerRef,
+			Request:   csrPEM,
+			Usages:    cert.Spec.Usages,
+		},
+	}
+	if err := d.Client.Create(ctx, cr); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to create CertificateRequest: %v", err)
+	}
+	issued := false
+	defer func() {
+		if !issued {
+			d.deleteRequest(ctx, cr.Namespace, cr.Name)
+		}
+	}()
+
+	interval := d.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	var failure error
+	err := wait.PollUntilContextCancel(ctx, interval, false, func(ctx context.Context) (bool, error) {
+		if err := d.Reader.Get(ctx, client.ObjectKeyFromObject(cr), cr); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, err
+			}
+			// Wait out API hiccups until ctx is done
+			return false, nil
+		}
+		failure = certificateRequestFailure(cr)
+		return failure != nil || len(cr.Status.Certificate) > 0, nil
+	})
+	if failure != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "CertificateRequest %s: %v", cr.Name, failure)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "CertificateRequest %s hasn't been issued: %v", cr.Name, err)
+	}
+	issued = true
+	return cr, nil
+}
+
+// deleteRequest deletes the CertificateRequest name, if there is one.
+func (d *CSIDriver) deleteRequest(ctx context.Context, namespace, name string) {
+	if name == "" {
+		return
+	}
+	cr := &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	if err := d.Client.Delete(context.WithoutCancel(ctx), cr); client.IgnoreNotFound(err) != nil {
+		logf.FromContext(ctx).Error(err, "Failed to delete CertificateRequest", "certificateRequest", name)
+	}
+}
+
+// certificateRequestFailure returns why cert-manager won't issue cr, if it
+// won't.
+func certificateRequestFailure(cr *certmanagerv1.CertificateRequest) error {
+	for _, cond := range cr.Status.Conditions {
+		switch {
+		case cond.Type == certmanagerv1.CertificateRequestConditionDenied && cond.Status == cmmeta.ConditionTrue:
+			return fmt.Errorf("denied: %s", cond.Message)
+		case cond.Type == certmanagerv1.CertificateRequestConditionInvalidRequest && cond.Status == cmmeta.ConditionTrue:
+			return fmt.Errorf("invalid: %s", cond.Message)
+		case cond.Type == certmanagerv1.CertificateRequestConditionReady && cond.Status == cmmeta.ConditionFalse &&
+			cond.Reason == certmanagerv1.CertificateRequestReasonFailed:
+			return fmt.Errorf("failed: %s", cond.Message)
+		}
+	}
+	return nil
+}
+
+// renewBefore returns how long before its expiry a certificate is renewed:
+// the Certificate's renewBefore if it leaves some of the lifetime, and a
+// third of the lifetime as with cert-manager otherwise.
+func renewBefore(leaf *x509.Certificate, configured *metav1.Duration) time.Duration {
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	if configured != nil && configured.Duration > 0 && configured.Duration < lifetime {
+		return configured.Duration
+	}
+	return lifetime / 3
+}
+
+// generatePrivateKey generates a private key as cert-manager would for the
+// Certificate's spec.privateKey.
+func generatePrivateKey(spec *certmanagerv1.CertificatePrivateKey) (crypto.Signer, error) {
+	algorithm, size := certmanagerv1.RSAKeyAlgorithm, 0
+	if spec != nil {
+		if spec.Algorithm != "" {
+			algorithm = spec.Algorithm
+		}
+		size = spec.Size
+	}
+
+	switch algorithm {
+	case certmanagerv1.RSAKeyAlgorithm:
+		if size == 0 {
+			size = 2048
+		}
+		return rsa.GenerateKey(rand.Reader, size)
+	case certmanagerv1.ECDSAKeyAlgorithm:
+		curves := map[int]elliptic.Curve{0: elliptic.P256(), 256: elliptic.P256(), 384: elliptic.P384(), 521: elliptic.P521()}
+		curve, ok := curves[size]
+		if !ok {
+			return nil, fmt.Errorf("unsupported ECDSA key size %d", size)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case certmanagerv1.Ed25519KeyAlgorithm:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %s", algorithm)
+	}
+}
+
+// encodePrivateKey PEM encodes key in the Certificate's
+// spec.privateKey.encoding.
+func encodePrivateKey(key crypto.Signer, spec *certmanagerv1.CertificatePrivateKey) ([]byte, error) {
+	if spec != nil && spec.Encoding == certmanagerv1.PKCS1 {
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+		case *ecdsa.PrivateKey:
+			der, err := x509.MarshalECPrivateKey(key)
+			if err != nil {
+				return nil, err
+			}
+			return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+		}
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// certificateSigningRequest returns a PEM encoded CSR for the names of the
+// Certificate, which the operator renders from the claim.
+func certificateSigningRequest(cert *certmanagerv1.Certificate, key crypto.Signer) ([]byte, error) {
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: cert.Spec.CommonName},
+		DNSNames: cert.Spec.DNSNames,
+	}
+	for _, raw := range cert.Spec.URIs {
+		uri, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI %q: %w", raw, err)
+		}
+		template.URIs = append(template.URIs, uri)
+	}
+	for _, raw := range cert.Spec.IPAddresses {
+		ip := net.ParseIP(raw)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", raw)
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// volumeFile is a file of a volume.
+type volumeFile struct {
+	name string
+	data []byte
+}
+
+// write replaces the files of the volume one by one, so readers never see a
+// partially written file.
+func (v *csiVolume) write(files []volumeFile) error {
+	mode := os.FileMode(0o644)
+	if v.FSGroup != nil {
+		mode = 0o640
+	}
+	for _, file := range files {
+		tmp := filepath.Join(v.TargetPath, "."+file.name+".tmp")
+		if err := os.WriteFile(tmp, file.data, mode); err != nil {
+			return err
+		}
+		if v.FSGroup != nil {
+			if err := os.Chown(tmp, -1, int(*v.FSGroup)); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(tmp, filepath.Join(v.TargetPath, file.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statePath returns where the state of the volume is recorded.
+func (d *CSIDriver) statePath(id string) string {
+	return filepath.Join(d.StateDir, url.PathEscape(id)+".json")
+}
+
+// save records the state of vol.
+func (d *CSIDriver) save(vol *csiVolume) error {
+	data, err := json.Marshal(vol)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.StateDir, 0o700); err != nil {
+		return err
+	}
+	tmp := d.statePath(vol.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.statePath(vol.ID))
+}
+
+// forget removes the recorded state of a volume.
+func (d *CSIDriver) forget(id string) error {
+	if err := os.Remove(d.statePath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// load resumes the volumes recorded in the state directory. Volumes whose
+// tmpfs has gone, such as after a reboot, are forgotten; the kubelet
+// publishes them again.
+func (d *CSIDriver) load(ctx context.Context) error {
+	log := logf.FromContext(ctx)
+
+	entries, err := os.ReadDir(d.StateDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSI state: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.volumes == nil {
+		d.volumes = map[string]*csiVolume{}
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(d.StateDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read CSI state: %w", err)
+		}
+		vol := &csiVolume{}
+		if err := json.Unmarshal(data, vol); err != nil {
+			log.Error(err, "Ignoring invalid CSI volume state", "file", entry.Name())
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(vol.TargetPath, corev1.TLSCertKey)); err != nil {
+			log.Info("Forgetting volume whose files are gone", "volume", vol.ID)
+			if err := d.forget(vol.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := d.volumes[vol.ID]; !ok {
+			d.volumes[vol.ID] = vol
+		}
+	}
+	return nil
+}
+
+// unmount unmounts the tmpfs at target and removes the directory.
+func (d *CSIDriver) unmount(target string) error {
+	if err := d.Mounter.Unmount(target); err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// ErrPodNotFound is returned for callers whose pod the agent doesn't know,
+// such as processes outside of pods or pods of other nodes.
+var ErrPodNotFound = errors.New("pod not found on this node")
+
+// X509Identity is the X.509 identity a pod gets from one of its IdentityClaims.
+type X509Identity struct {
+	// SVID is the certificate chain and private key, hinted with the claim name.
+	SVID *x509svid.SVID
+	// Bundle holds the CA certificates of the Secret, if the issuer provides any.
+	Bundle *x509bundle.Bundle
+}
+
+// Identities reads the identities of pods from the Secrets of the
+// IdentityClaims selecting them.
+type Identities struct {
+	// Client reads Pods and IdentityClaims, usually from the agent's cache.
+	Client client.Reader
+	// APIReader reads the identity Secrets and the Certificates of claims,
+	// which the agent doesn't cache.
+	APIReader client.Reader
+}
+
+// Pod returns the pod with the given UID.
+func (s *Identities) Pod(ctx context.Context, uid types.UID) (*corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := s.Client.List(ctx, pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range pods.Items {
+		if pods.Items[i].UID == uid {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, ErrPodNotFound
+}
+
+// Claims returns the IdentityClaims selecting the pod that may be served,
+// oldest first, so the first one is the default as with injected identities.
+// Only Ready claims whose Certificate they control are served: the operator
+// holds back failed, refused and suspended claims, and a Certificate it
+// doesn't control could have been written by anyone.
+func (s *Identities) Claims(ctx context.Context, pod *corev1.Pod) ([]identityv1alpha1.IdentityClaim, error) {
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := s.Client.List(ctx, claims, client.InNamespace(pod.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list IdentityClaims: %w", err)
+	}
+	slices.SortFunc(claims.Items, func(a, b identityv1alpha1.IdentityClaim) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	claims.Items = slices.DeleteFunc(claims.Items, func(claim identityv1alpha1.IdentityClaim) bool {
+		if claim.Status.SecretName == "" || !claim.Servable() {
+			return true
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
+		return err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels))
+	})
+
+	served := claims.Items[:0]
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		cert := &certmanagerv1.Certificate{}
+		key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}
+		if err := s.APIReader.Get(ctx, key, cert); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get Certificate %s: %w", key.Name, err)
+		}
+		if metav1.IsControlledBy(cert, claim) {
+			served = append(served, *claim)
+		}
+	}
+	return served, nil
+}
+
+// X509 returns the identities of the pod in the order of Claims. Claims whose
+// Secret hasn't been issued for their Certificate yet are left out.
+func (s *Identities) X509(ctx context.Context, pod *corev1.Pod) ([]X509Identity, error) {
+	log := logf.FromContext(ctx)
+
+	claims, err := s.Claims(ctx, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	var identities []X509Identity
+	for i := range claims {
+		claim := &claims[i]
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}
+		if err := s.APIReader.Get(ctx, key, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get Secret %s: %w", key.Name, err)
+		}
+		if secret.Annotations[certmanagerv1.CertificateNameKey] != claim.Status.SecretName {
+			// Not written by cert-manager for the claim's Certificate
+			log.V(1).Info("Skipping identity Secret", "claim", claim.Name, "secret", key.Name,
+				"reason", "not issued for the claim's Certificate")
+			continue
+		}
+		identity, err := parseIdentity(secret)
+		if err != nil {
+			// Being issued, or not written by cert-manager
+			log.V(1).Info("Skipping identity Secret", "claim", claim.Name, "secret", key.Name, "reason", err.Error())
+			continue
+		}
+		identity.SVID.Hint = claim.Name
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// parseIdentity reads the SVID and CA bundle from an identity Secret.
+func parseIdentity(secret *corev1.Secret) (X509Identity, error) {
+	certs, err := parseCertificates(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return X509Identity{}, fmt.Errorf("%s: %w", corev1.TLSCertKey, err)
+	}
+	key, err := parsePrivateKey(secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return X509Identity{}, fmt.Errorf("%s: %w", corev1.TLSPrivateKeyKey, err)
+	}
+	var rawCerts []byte
+	for _, cert := range certs {
+		rawCerts = append(rawCerts, cert.Raw...)
+	}
+	svid, err := x509svid.ParseRaw(rawCerts, key)
+	if err != nil {
+		return X509Identity{}, err
+	}
+
+	bundle := x509bundle.New(svid.ID.TrustDomain())
+	if ca := secret.Data[cmmeta.TLSCAKey]; len(ca) > 0 {
+		authorities, err := parseCertificates(ca)
+		if err != nil {
+			return X509Identity{}, fmt.Errorf("%s: %w", cmmeta.TLSCAKey, err)
+		}
+		bundle.SetX509Authorities(authorities)
+	}
+	return X509Identity{SVID: svid, Bundle: bundle}, nil
+}
+
+// parseCertificates parses PEM encoded certificates.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// parsePrivateKey parses a PEM encoded private key in any of the encodings of
+// spec.privateKey.encoding and returns it PKCS#8 encoded, as the Workload API
+// serves it.
+func parsePrivateKey(data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no private key found")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		return block.Bytes, nil
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+// bundles merges the bundles of the identities by trust domain, so every
+// identity trusts the CAs of all issuers of the pod's identities.
+func bundles(identities []X509Identity) map[spiffeid.TrustDomain]*x509bundle.Bundle {
+	merged := map[spiffeid.TrustDomain]*x509bundle.Bundle{}
+	for _, identity := range identities {
+		td := identity.Bundle.TrustDomain()
+		bundle, ok := merged[td]
+		if !ok {
+			bundle = x509bundle.New(td)
+			merged[td] = bundle
+		}
+		for _, authority := range identity.Bundle.X509Authorities() {
+			bundle.AddX509Authority(authority)
+		}
+	}
+	return merged
+}
+
+// rawBundle concatenates the DER encoded authorities of a bundle.
+func rawBundle(bundle *x509bundle.Bundle) []byte {
+	var raw []byte
+	for _, authority := range bundle.X509Authorities() {
+		raw = append(raw, authority.Raw...)
+	}
+	return raw
+}
@@ -0,0 +1,26 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+// Mounter mounts the in-memory filesystems CSI volumes are written to, so
+// their private keys never touch the node's disk.
+type Mounter interface {
+	// Mount mounts an empty tmpfs at target, creating the directory.
+	Mount(target string) error
+	// Unmount unmounts target. Targets that aren't mounted are ignored.
+	Unmount(target string) error
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tmpfsSize limits each volume; it only holds a certificate chain and key.
+const tmpfsSize = "size=1m"
+
+// TmpfsMounter mounts tmpfs filesystems. It needs CAP_SYS_ADMIN, and the
+// kubelet's pod directory must be mounted with bidirectional propagation for
+// pods to see the mounts.
+type TmpfsMounter struct{}
+
+// Mount implements Mounter.
+func (TmpfsMounter) Mount(target string) error {
+	if err := os.MkdirAll(target, 0o750); err != nil {
+		return err
+	}
+	flags := uintptr(unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC)
+	if err := unix.Mount("tmpfs", target, "tmpfs", flags, tmpfsSize+",mode=0755"); err != nil {
+		return fmt.Errorf("failed to mount tmpfs at %s: %w", target, err)
+	}
+	return nil
+}
+
+// Unmount implements Mounter.
+func (TmpfsMounter) Unmount(target string) error {
+	err := unix.Unmount(target, 0)
+	if err == nil || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOENT) {
+		return nil
+	}
+	return fmt.Errorf("failed to unmount %s: %w", target, err)
+}
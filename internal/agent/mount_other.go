@@ -0,0 +1,34 @@
+//go:build !linux
+
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import "errors"
+
+// TmpfsMounter mounts tmpfs filesystems, which is only supported on Linux.
+type TmpfsMounter struct{}
+
+// Mount implements Mounter.
+func (TmpfsMounter) Mount(string) error {
+	return errors.New("tmpfs mounts are only supported on Linux")
+}
+
+// Unmount implements Mounter.
+func (TmpfsMounter) Unmount(string) error {
+	return errors.New("tmpfs mounts are only supported on Linux")
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// callerInfo is the credentials.AuthInfo of a connection on the agent's socket.
+type callerInfo struct {
+	credentials.CommonAuthInfo
+	// pid is the process that opened the connection.
+	pid int32
+}
+
+// AuthType implements credentials.AuthInfo.
+func (callerInfo) AuthType() string {
+	return "peercred"
+}
+
+// peerCredentials are gRPC transport credentials for Unix sockets. They don't
+// encrypt anything; they record which process connected, so callers can be
+// attested without presenting credentials of their own.
+type peerCredentials struct{}
+
+// ClientHandshake implements credentials.TransportCredentials.
+func (peerCredentials) ClientHandshake(context.Context, string, net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("peer credentials are only supported by servers")
+}
+
+// ServerHandshake implements credentials.TransportCredentials.
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, errors.New("peer credentials require a Unix socket")
+	}
+	pid, err := peerPID(unixConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, callerInfo{
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		pid:            pid,
+	}, nil
+}
+
+// Info implements credentials.TransportCredentials.
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+// Clone implements credentials.TransportCredentials.
+func (c peerCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+// OverrideServerName implements credentials.TransportCredentials.
+func (peerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// callerPID returns the process that opened the connection of a request.
+func callerPID(ctx context.Context) (int32, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	info, ok := p.AuthInfo.(callerInfo)
+	if !ok {
+		return 0, false
+	}
+	return info.pid, true
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	secretv3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// secretTypeURL is the type of the resources served by SDS.
+const secretTypeURL = "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret"
+
+// SDS serves the identities of the IdentityClaims selecting the caller's pod
+// to Envoy over the Secret Discovery Service. A tls_certificate is named by
+// its SPIFFE ID and a validation_context by the SPIFFE ID of its trust domain,
+// such as spiffe://cluster.local. Envoy is authenticated by the pod it runs
+// in, like callers of the Workload API; the node it reports is not trusted.
+type SDS struct {
+	secretv3.UnimplementedSecretDiscoveryServiceServer
+
+	Attestor   Attestor
+	Identities *Identities
+	Notifier   *Notifier
+	// Resync defaults to DefaultResync.
+	Resync time.Duration
+}
+
+// StreamSecrets implements secretv3.SecretDiscoveryServiceServer. It follows
+// the state of the world variant of the xDS protocol: every response carries
+// all resources Envoy subscribed to, and a new response is only sent when
+// they change or Envoy subscribes to other resources.
+func (s *SDS) StreamSecrets(stream secretv3.SecretDiscoveryService_StreamSecretsServer) error {
+	ctx := stream.Context()
+	log := logf.FromContext(ctx)
+
+	uid, err := attestCaller(ctx, s.Attestor)
+	if err != nil {
+		return err
+	}
+	// A pod's namespace never changes; it scopes the change notifications
+	caller, err := callerPod(ctx, s.Identities, uid)
+	if err != nil {
+		return err
+	}
+	resync := s.Resync
+	if resync <= 0 {
+		resync = DefaultResync
+	}
+
+	requests := make(chan *discoveryv3.DiscoveryRequest)
+	recvErrs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErrs <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var names []string
+	subscribed := false
+	var version, nonce string
+	var sent int
+	for {
+		changed := s.Notifier.Changed(caller.Namespace)
+		resubscribed := false
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-recvErrs:
+			if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+				return nil
+			}
+			return err
+		case req := <-requests:
+			if req.TypeUrl != "" && req.TypeUrl != secretTypeURL {
+				return status.Errorf(codes.InvalidArgument, "unsupported resource type %s", req.TypeUrl)
+			}
+			if req.ResponseNonce != nonce {
+				// Superseded by a response Envoy hasn't seen yet
+				continue
+			}
+			if req.ErrorDetail != nil {
+				log.Info("Envoy rejected secrets", "node", req.GetNode().GetId(), "version", version,
+					"error", req.ErrorDetail.Message)
+			}
+			if subscribed && slices.Equal(req.ResourceNames, names) {
+				// Acknowledged
+				continue
+			}
+			names = req.ResourceNames
+			subscribed = true
+			resubscribed = true
+		case <-changed:
+		case <-time.After(resync):
+		}
+		if !subscribed {
+			continue
+		}
+
+		resp, err := s.response(ctx, uid, names)
+		if err != nil {
+			return err
+		}
+		if !resubscribed && resp.VersionInfo == version {
+			continue
+		}
+		sent++
+		resp.Nonce = strconv.Itoa(sent)
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+		version, nonce = resp.VersionInfo, resp.Nonce
+		log.V(1).Info("Sent secrets", "pod", caller.Namespace+"/"+caller.Name, "version", version,
+			"secrets", len(resp.Resources))
+	}
+}
+
+// FetchSecrets implements secretv3.SecretDiscoveryServiceServer.
+func (s *SDS) FetchSecrets(ctx context.Context, req *discoveryv3.DiscoveryRequest) (*discoveryv3.DiscoveryResponse, error) {
+	if req.TypeUrl != "" && req.TypeUrl != secretTypeURL {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported resource type %s", req.TypeUrl)
+	}
+	uid, err := attestCaller(ctx, s.Attestor)
+	if err != nil {
+		return nil, err
+	}
+	return s.response(ctx, uid, req.ResourceNames)
+}
+
+// response returns the requested secrets of the pod, or all of them when no
+// names are given. Secrets the pod doesn't have are left out, so Envoy keeps
+// waiting for them. The version is a hash of the secrets.
+func (s *SDS) response(ctx context.Context, uid types.UID, names []string) (*discoveryv3.DiscoveryResponse, error) {
+	pod, err := callerPod(ctx, s.Identities, uid)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.Identities.X509(ctx, pod)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	secrets, err := sdsSecrets(identities)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build secrets: %v", err)
+	}
+
+	resp := &discoveryv3.DiscoveryResponse{TypeUrl: secretTypeURL}
+	hash := sha256.New()
+	for _, secret := range secrets {
+		if len(names) > 0 && !slices.Contains(names, secret.Name) {
+			continue
+		}
+		resource, err := anypb.New(secret)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal secret %s: %v", secret.Name, err)
+		}
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(secret)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal secret %s: %v", secret.Name, err)
+		}
+		hash.Write(data)
+		resp.Resources = append(resp.Resources, resource)
+	}
+	resp.VersionInfo = hex.EncodeToString(hash.Sum(nil))[:16]
+	return resp, nil
+}
+
+// sdsSecrets returns a tls_certificate for every identity and a
+// validation_context for every trust domain of the identities. An identity
+// repeated by several claims is served from the oldest one.
+func sdsSecrets(identities []X509Identity) ([]*tlsv3.Secret, error) {
+	var secrets []*tlsv3.Secret
+	seen := map[string]bool{}
+	for _, identity := range identities {
+		name := identity.SVID.ID.String()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		certs, key, err := identity.SVID.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal SVID %s: %w", name, err)
+		}
+		secrets = append(secrets, &tlsv3.Secret{
+			Name: name,
+			Type: &tlsv3.Secret_TlsCertificate{TlsCertificate: &tlsv3.TlsCertificate{
+				CertificateChain: inlineBytes(certs),
+				PrivateKey:       inlineBytes(key),
+			}},
+		})
+	}
+
+	merged := bundles(identities)
+	tds := make([]spiffeid.TrustDomain, 0, len(merged))
+	for td := range merged {
+		tds = append(tds, td)
+	}
+	slices.SortFunc(tds, func(a, b spiffeid.TrustDomain) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	for _, td := range tds {
+		bundle := merged[td]
+		if bundle.Empty() {
+			// The issuer doesn't publish its CA in the Secret
+			continue
+		}
+		pem, err := bundle.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal bundle of %s: %w", td, err)
+		}
+		secrets = append(secrets, &tlsv3.Secret{
+			Name: td.IDString(),
+			Type: &tlsv3.Secret_ValidationContext{ValidationContext: &tlsv3.CertificateValidationContext{
+				TrustedCa: inlineBytes(pem),
+			}},
+		})
+	}
+	return secrets, nil
+}
+
+func inlineBytes(data []byte) *corev3.DataSource {
+	return &corev3.DataSource{Specifier: &corev3.DataSource_InlineBytes{InlineBytes: data}}
+}
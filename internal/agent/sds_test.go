@@ -0,0 +1,134 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	secretv3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certificateSerial returns the serial of the leaf certificate of a
+// tls_certificate secret.
+func certificateSerial(secret *tlsv3.Secret) int64 {
+	block, _ := pem.Decode(secret.GetTlsCertificate().GetCertificateChain().GetInlineBytes())
+	Expect(block).NotTo(BeNil())
+	cert, err := x509.ParseCertificate(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	return cert.SerialNumber.Int64()
+}
+
+// unpackSecrets returns the secrets of a response by name.
+func unpackSecrets(resp *discoveryv3.DiscoveryResponse) map[string]*tlsv3.Secret {
+	secrets := map[string]*tlsv3.Secret{}
+	for _, resource := range resp.Resources {
+		secret := &tlsv3.Secret{}
+		Expect(resource.UnmarshalTo(secret)).To(Succeed())
+		secrets[secret.Name] = secret
+	}
+	return secrets
+}
+
+var _ = Describe("SDS", func() {
+	const bundleName = "spiffe://cluster.local"
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		ca       *testCA
+		c        client.Client
+		notifier *Notifier
+		sds      secretv3.SecretDiscoveryServiceClient
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(func() { cancel() })
+		ca = newTestCA()
+		c = newIssuedClient(ca)
+		notifier = NewNotifier()
+
+		server := &SDS{
+			Attestor:   podAttestor(podUID),
+			Identities: &Identities{Client: c, APIReader: c},
+			Notifier:   notifier,
+		}
+		socketPath := serve(ctx, func(s grpc.ServiceRegistrar) {
+			secretv3.RegisterSecretDiscoveryServiceServer(s, server)
+		})
+		conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		sds = secretv3.NewSecretDiscoveryServiceClient(conn)
+	})
+
+	It("should stream the certificate and trust bundle and push renewals", func() {
+		stream, err := sds.StreamSecrets(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Send(&discoveryv3.DiscoveryRequest{
+			TypeUrl:       secretTypeURL,
+			ResourceNames: []string{spiffeID, bundleName},
+		})).To(Succeed())
+
+		resp, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		secrets := unpackSecrets(resp)
+		Expect(secrets).To(HaveLen(2))
+		Expect(certificateSerial(secrets[spiffeID])).To(Equal(int64(2)))
+		Expect(secrets[bundleName].GetValidationContext().GetTrustedCa().GetInlineBytes()).To(
+			Equal(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})))
+
+		By("acknowledging the response")
+		Expect(stream.Send(&discoveryv3.DiscoveryRequest{
+			TypeUrl:       secretTypeURL,
+			VersionInfo:   resp.VersionInfo,
+			ResponseNonce: resp.Nonce,
+			ResourceNames: []string{spiffeID, bundleName},
+		})).To(Succeed())
+
+		By("renewing the certificate")
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: secretName}, secret)).To(Succeed())
+		secret.Data = ca.secretData(spiffeID, 3)
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		notifier.Notify("default")
+
+		renewed, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renewed.VersionInfo).NotTo(Equal(resp.VersionInfo))
+		Expect(renewed.Nonce).NotTo(Equal(resp.Nonce))
+		Expect(certificateSerial(unpackSecrets(renewed)[spiffeID])).To(Equal(int64(3)))
+	})
+
+	It("should not serve identities of other pods", func() {
+		resp, err := sds.FetchSecrets(ctx, &discoveryv3.DiscoveryRequest{
+			TypeUrl:       secretTypeURL,
+			ResourceNames: []string{"spiffe://cluster.local/ns/default/ic/db"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Resources).To(BeEmpty())
+	})
+})
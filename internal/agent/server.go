@@ -0,0 +1,80 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Server serves gRPC services on a Unix socket to the processes of the node.
+// It runs on every agent, not just the leader.
+type Server struct {
+	// Path of the socket. A socket left behind by a previous agent is replaced.
+	Path string
+	// Register registers the services to serve.
+	Register func(grpc.ServiceRegistrar)
+}
+
+// Start implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithValues("socket", s.Path)
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Path, err)
+	}
+	// Any process may connect; callers are told apart by attestation
+	if err := os.Chmod(s.Path, 0o777); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to open up socket: %w", err)
+	}
+
+	server := grpc.NewServer(grpc.Creds(peerCredentials{}))
+	s.Register(server)
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info("Serving")
+		errs <- server.Serve(listener)
+	}()
+	select {
+	case <-ctx.Done():
+		// Streams never end on their own, so don't wait for them
+		server.Stop()
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
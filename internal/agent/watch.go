@@ -0,0 +1,91 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// Notifier tells the streams of a namespace that its identities may have changed.
+type Notifier struct {
+	mu      sync.Mutex
+	changed map[string]chan struct{}
+}
+
+// NewNotifier returns a Notifier without pending notifications.
+func NewNotifier() *Notifier {
+	return &Notifier{changed: map[string]chan struct{}{}}
+}
+
+// Changed returns a channel that is closed on the next notification for the
+// namespace. Callers get it before reading the identities, so changes made in
+// between aren't missed.
+func (n *Notifier) Changed(namespace string) <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch, ok := n.changed[namespace]
+	if !ok {
+		ch = make(chan struct{})
+		n.changed[namespace] = ch
+	}
+	return ch
+}
+
+// Notify wakes up the streams waiting on the namespace.
+func (n *Notifier) Notify(namespace string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if ch, ok := n.changed[namespace]; ok {
+		close(ch)
+		delete(n.changed, namespace)
+	}
+}
+
+// ChangeReconciler notifies the streams of a namespace when an IdentityClaim
+// or a pod of the agent's node in it changes. The operator updates the status
+// of a claim when cert-manager renews or rotates its certificate, so the new
+// Secret is picked up without watching Secrets.
+type ChangeReconciler struct {
+	Notifier *Notifier
+}
+
+// Reconcile implements reconcile.Reconciler.
+func (r *ChangeReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Notifier.Notify(req.Namespace)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the reconciler with the Manager.
+func (r *ChangeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&identityv1alpha1.IdentityClaim{}).
+		// Only label changes can change which claims select a pod.
+		Watches(&corev1.Pod{},
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Named("agent").
+		Complete(r)
+}
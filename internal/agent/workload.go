@@ -0,0 +1,150 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// workloadHeader must be set on every Workload API request, so the API can't
+// be called through a proxy that forwards arbitrary requests.
+const workloadHeader = "workload.spiffe.io"
+
+// DefaultResync is how often streams re-read the identities of their pod
+// without a change notification.
+const DefaultResync = 5 * time.Minute
+
+// WorkloadAPI serves the X.509 part of the SPIFFE Workload API. Callers are
+// attested by the process that opened the connection; they get the
+// identities of the IdentityClaims selecting their pod.
+// See https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE_Workload_API.md
+type WorkloadAPI struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+
+	Attestor   Attestor
+	Identities *Identities
+	Notifier   *Notifier
+	// Resync defaults to DefaultResync.
+	Resync time.Duration
+}
+
+// FetchX509SVID implements workload.SpiffeWorkloadAPIServer.
+func (w *WorkloadAPI) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	return watch(stream.Context(), w, x509SVIDResponse, stream.Send)
+}
+
+// FetchX509Bundles implements workload.SpiffeWorkloadAPIServer.
+func (w *WorkloadAPI) FetchX509Bundles(_ *workload.X509BundlesRequest, stream workload.SpiffeWorkloadAPI_FetchX509BundlesServer) error {
+	return watch(stream.Context(), w, x509BundlesResponse, stream.Send)
+}
+
+// attest checks the security header and returns the UID of the pod that
+// called the API.
+func (w *WorkloadAPI) attest(ctx context.Context) (types.UID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(workloadHeader); len(values) != 1 || values[0] != "true" {
+		return "", status.Errorf(codes.InvalidArgument, "security header %s is missing", workloadHeader)
+	}
+	return attestCaller(ctx, w.Attestor)
+}
+
+// watch sends the response built from the caller's identities, and sends it
+// again whenever it changes, until the caller goes away. A caller without
+// identities is denied, as the Workload API asks, and retries.
+func watch[T proto.Message](ctx context.Context, w *WorkloadAPI, build func([]X509Identity) (T, error), send func(T) error) error {
+	log := logf.FromContext(ctx)
+
+	uid, err := w.attest(ctx)
+	if err != nil {
+		return err
+	}
+	resync := w.Resync
+	if resync <= 0 {
+		resync = DefaultResync
+	}
+
+	// A pod's namespace never changes; it scopes the change notifications
+	caller, err := callerPod(ctx, w.Identities, uid)
+	if err != nil {
+		return err
+	}
+
+	var last T
+	for {
+		changed := w.Notifier.Changed(caller.Namespace)
+		pod, identities, err := callerIdentities(ctx, w.Identities, uid)
+		if err != nil {
+			return err
+		}
+
+		resp, err := build(identities)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to build response: %v", err)
+		}
+		if !proto.Equal(resp, last) {
+			if err := send(resp); err != nil {
+				return err
+			}
+			log.V(1).Info("Sent identities", "pod", pod.Namespace+"/"+pod.Name, "identities", len(identities))
+			last = resp
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-time.After(resync):
+		}
+	}
+}
+
+func x509SVIDResponse(identities []X509Identity) (*workload.X509SVIDResponse, error) {
+	merged := bundles(identities)
+	resp := &workload.X509SVIDResponse{}
+	for _, identity := range identities {
+		certs, key, err := identity.SVID.MarshalRaw()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal SVID %s: %w", identity.SVID.ID, err)
+		}
+		resp.Svids = append(resp.Svids, &workload.X509SVID{
+			SpiffeId:    identity.SVID.ID.String(),
+			X509Svid:    certs,
+			X509SvidKey: key,
+			Bundle:      rawBundle(merged[identity.SVID.ID.TrustDomain()]),
+			Hint:        identity.SVID.Hint,
+		})
+	}
+	return resp, nil
+}
+
+func x509BundlesResponse(identities []X509Identity) (*workload.X509BundlesResponse, error) {
+	resp := &workload.X509BundlesResponse{Bundles: map[string][]byte{}}
+	for td, bundle := range bundles(identities) {
+		resp.Bundles[td.IDString()] = rawBundle(bundle)
+	}
+	return resp, nil
+}
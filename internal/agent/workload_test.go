@@ -0,0 +1,315 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// podAttestor attests every caller as the same pod.
+type podAttestor types.UID
+
+func (a podAttestor) PodUID(int32) (types.UID, error) {
+	return types.UID(a), nil
+}
+
+// testCA issues SVIDs like a cert-manager CA issuer.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA() *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return &testCA{cert: cert, key: key}
+}
+
+// secretData returns the data of an identity Secret for spiffeID, with the
+// key SEC 1 encoded as with spec.privateKey.encoding PKCS1.
+func (ca *testCA) secretData(spiffeID string, serial int64) map[string][]byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	uri, err := url.Parse(spiffeID)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	return map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		cmmeta.TLSCAKey:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}),
+	}
+}
+
+const (
+	podUID     = types.UID("3e61c214-bc3e-11e9-87f2-0a58ac1e0074")
+	secretName = "web-identity"
+	spiffeID   = "spiffe://cluster.local/ns/default/ic/web"
+)
+
+// newIssuedClient returns a client holding a pod selected by a Ready
+// IdentityClaim whose Certificate and Secret the CA has issued.
+func newIssuedClient(ca *testCA) client.Client {
+	claim := &identityv1alpha1.IdentityClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "9a1e0c4e-5b0f-4d43-a4b4-1f6f0c9b2e1a"},
+		Spec: identityv1alpha1.IdentityClaimSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: identityv1alpha1.IdentityClaimStatus{
+			Phase:      identityv1alpha1.PhaseReady,
+			SecretName: secretName,
+			SpiffeID:   spiffeID,
+			Conditions: []metav1.Condition{{
+				Type:   identityv1alpha1.ConditionReady,
+				Status: metav1.ConditionTrue,
+				Reason: "Ready",
+			}},
+		},
+	}
+	cert := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+		Spec: certmanagerv1.CertificateSpec{
+			SecretName:  secretName,
+			Duration:    &metav1.Duration{Duration: time.Hour},
+			RenewBefore: &metav1.Duration{Duration: 20 * time.Minute},
+			URIs:        []string{spiffeID},
+			CommonName:  "web",
+			IssuerRef:   cmmeta.ObjectReference{Name: "ca-issuer", Kind: "ClusterIssuer"},
+			PrivateKey: &certmanagerv1.CertificatePrivateKey{
+				Algorithm: certmanagerv1.ECDSAKeyAlgorithm,
+				Size:      256,
+			},
+		},
+	}
+	Expect(controllerutil.SetControllerReference(claim, cert, scheme.Scheme)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-0",
+				Namespace: "default",
+				UID:       podUID,
+				Labels:    map[string]string{"app": "web"},
+			}},
+			claim, cert,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secretName,
+					Namespace:   "default",
+					Annotations: map[string]string{certmanagerv1.CertificateNameKey: secretName},
+				},
+				Data: ca.secretData(spiffeID, 2),
+			}).
+		Build()
+}
+
+// serve serves the services registered by register on a new socket until ctx
+// is done, and returns the path of the socket.
+func serve(ctx context.Context, register func(grpc.ServiceRegistrar)) string {
+	// Unix socket paths are limited to about 100 characters
+	dir, err := os.MkdirTemp("", "agent")
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(os.RemoveAll, dir)
+	socketPath := filepath.Join(dir, "agent.sock")
+
+	server := &Server{Path: socketPath, Register: register}
+	go func() {
+		defer GinkgoRecover()
+		Expect(server.Start(ctx)).To(Succeed())
+	}()
+	Eventually(func() error {
+		_, err := os.Stat(socketPath)
+		return err
+	}).Should(Succeed())
+	return socketPath
+}
+
+var _ = Describe("WorkloadAPI", func() {
+	var (
+		ctx        context.Context
+		cancel     context.CancelFunc
+		ca         *testCA
+		c          client.Client
+		notifier   *Notifier
+		socketPath string
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(func() { cancel() })
+		ca = newTestCA()
+
+		c = newIssuedClient(ca)
+		notifier = NewNotifier()
+
+		api := &WorkloadAPI{
+			Attestor:   podAttestor(podUID),
+			Identities: &Identities{Client: c, APIReader: c},
+			Notifier:   notifier,
+		}
+		socketPath = serve(ctx, func(s grpc.ServiceRegistrar) {
+			workload.RegisterSpiffeWorkloadAPIServer(s, api)
+		})
+	})
+
+	It("should serve the SVID and bundle of the claim selecting the caller", func() {
+		x509Context, err := workloadapi.FetchX509Context(ctx, workloadapi.WithAddr("unix://"+socketPath))
+		Expect(err).NotTo(HaveOccurred())
+
+		svid := x509Context.DefaultSVID()
+		Expect(svid.ID.String()).To(Equal(spiffeID))
+		Expect(svid.Hint).To(Equal("web"))
+		bundle, err := x509Context.Bundles.GetX509BundleForTrustDomain(spiffeid.RequireTrustDomainFromString("cluster.local"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.HasX509Authority(ca.cert)).To(BeTrue())
+	})
+
+	It("should push the renewed SVID when the claim changes", func() {
+		conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		stream, err := workload.NewSpiffeWorkloadAPIClient(conn).FetchX509SVID(
+			metadata.AppendToOutgoingContext(ctx, workloadHeader, "true"), &workload.X509SVIDRequest{})
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Svids).To(HaveLen(1))
+		first := resp.Svids[0].X509Svid
+
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: secretName}, secret)).To(Succeed())
+		secret.Data = ca.secretData(spiffeID, 3)
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		notifier.Notify("default")
+
+		resp, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Svids).To(HaveLen(1))
+		Expect(resp.Svids[0].X509Svid).NotTo(Equal(first))
+		certs, err := x509.ParseCertificates(resp.Svids[0].X509Svid)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs[0].SerialNumber.Int64()).To(Equal(int64(3)))
+	})
+
+	It("should deny callers without an identity", func() {
+		pod := &corev1.Pod{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web-0"}, pod)).To(Succeed())
+		pod.Labels = map[string]string{"app": "db"}
+		Expect(c.Update(ctx, pod)).To(Succeed())
+
+		_, err := workloadapi.FetchX509SVID(ctx, workloadapi.WithAddr("unix://"+socketPath))
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("should not serve claims the operator holds back", func() {
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, claim)).To(Succeed())
+		claim.Spec.Suspend = true
+		Expect(c.Update(ctx, claim)).To(Succeed())
+
+		_, err := workloadapi.FetchX509SVID(ctx, workloadapi.WithAddr("unix://"+socketPath))
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("should not serve claims whose Certificate they don't control", func() {
+		By("replacing the Certificate with one the claim doesn't control")
+		Expect(c.Delete(ctx, &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+		})).To(Succeed())
+		Expect(c.Create(ctx, &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+		})).To(Succeed())
+		_, err := workloadapi.FetchX509SVID(ctx, workloadapi.WithAddr("unix://"+socketPath))
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("should require the Secret to name the claim's Certificate", func() {
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: secretName}, secret)).To(Succeed())
+		secret.Annotations[certmanagerv1.CertificateNameKey] = "other"
+		Expect(c.Update(ctx, secret)).To(Succeed())
+
+		_, err := workloadapi.FetchX509SVID(ctx, workloadapi.WithAddr("unix://"+socketPath))
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("should require the security header", func() {
+		conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		stream, err := workload.NewSpiffeWorkloadAPIClient(conn).FetchX509SVID(ctx, &workload.X509SVIDRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+})
@@ -0,0 +1,164 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// AgentAccessLabel marks the Roles and RoleBindings the AgentAccessReconciler
+// manages.
+const AgentAccessLabel = "identity.cluster.local/agent-access"
+
+// AgentAccessReconciler grants the node agent access to the identities it
+// serves, and nothing else: a Role in every namespace with IdentityClaims
+// allows getting the Secrets and Certificates named in the claims' status,
+// and creating CertificateRequests for CSI volumes if enabled.
+type AgentAccessReconciler struct {
+	client.Client
+	// ServiceAccount is the agent's ServiceAccount. The Roles and
+	// RoleBindings are named after it.
+	ServiceAccount types.NamespacedName
+	// CSI grants the agent the CertificateRequests of its CSI volumes.
+	CSI bool
+}
+
+// The operator can only grant what it may do itself, so it holds the
+// CertificateRequest verbs of the agent's CSI driver.
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificaterequests,verbs=create;get;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile writes the agent's Role and RoleBinding into the namespace named
+// by the request, or deletes them when the namespace has no identities.
+func (r *AgentAccessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	namespace := req.Name
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(namespace)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list IdentityClaims: %w", err)
+	}
+	var names []string
+	for _, claim := range claims.Items {
+		if claim.Status.SecretName != "" && !slices.Contains(names, claim.Status.SecretName) {
+			names = append(names, claim.Status.SecretName)
+		}
+	}
+	slices.Sort(names)
+
+	objectMeta := metav1.ObjectMeta{Name: r.ServiceAccount.Name, Namespace: namespace}
+	role := &rbacv1.Role{ObjectMeta: objectMeta}
+	binding := &rbacv1.RoleBinding{ObjectMeta: objectMeta}
+	if len(names) == 0 {
+		// A rule without resourceNames would grant every Secret
+		for _, obj := range []client.Object{binding, role} {
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		setAgentAccessLabel(role)
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			Verbs:         []string{"get"},
+			ResourceNames: names,
+		}, {
+			APIGroups:     []string{certmanagerv1.SchemeGroupVersion.Group},
+			Resources:     []string{"certificates"},
+			Verbs:         []string{"get"},
+			ResourceNames: names,
+		}}
+		if r.CSI {
+			role.Rules = append(role.Rules, rbacv1.PolicyRule{
+				APIGroups: []string{certmanagerv1.SchemeGroupVersion.Group},
+				Resources: []string{"certificaterequests"},
+				Verbs:     []string{"create", "get", "delete"},
+			})
+		}
+		return nil
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to write Role %s/%s: %w", namespace, role.Name, err)
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		setAgentAccessLabel(binding)
+		// The roleRef of a RoleBinding is immutable; it never changes here
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		}
+		binding.Subjects = []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      r.ServiceAccount.Name,
+			Namespace: r.ServiceAccount.Namespace,
+		}}
+		return nil
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to write RoleBinding %s/%s: %w", namespace, binding.Name, err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// setAgentAccessLabel adds AgentAccessLabel to obj.
+func setAgentAccessLabel(obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[AgentAccessLabel] = "true"
+	obj.SetLabels(labels)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AgentAccessReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueueNamespace := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+	})
+	managed := builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[AgentAccessLabel] == "true"
+	}))
+	return ctrl.NewControllerManagedBy(mgr).
+		// Status changes matter too: they carry the claim's Secret name.
+		Watches(&identityv1alpha1.IdentityClaim{}, enqueueNamespace).
+		// Put back what someone else changed
+		Watches(&rbacv1.Role{}, enqueueNamespace, managed).
+		Watches(&rbacv1.RoleBinding{}, enqueueNamespace, managed).
+		Named("agentaccess").
+		Complete(r)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+var _ = Describe("Agent access", func() {
+	ctx := context.Background()
+	agent := types.NamespacedName{Namespace: "identity-system", Name: "identity-agent"}
+	roleNN := types.NamespacedName{Namespace: "default", Name: agent.Name}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "default"}}
+
+	newClaim := func(name, secretName string) *identityv1alpha1.IdentityClaim {
+		return &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     identityv1alpha1.IdentityClaimStatus{SecretName: secretName},
+		}
+	}
+
+	It("should grant the agent only the identities of the namespace's claims", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(newClaim("web", "web-identity"), newClaim("db", "db-identity"), newClaim("new", "")).
+			Build()
+		r := &AgentAccessReconciler{Client: c, ServiceAccount: agent, CSI: true}
+
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+
+		role := &rbacv1.Role{}
+		Expect(c.Get(ctx, roleNN, role)).To(Succeed())
+		Expect(role.Labels).To(HaveKeyWithValue(AgentAccessLabel, "true"))
+		Expect(role.Rules).To(HaveLen(3))
+		Expect(role.Rules[0].Resources).To(Equal([]string{"secrets"}))
+		Expect(role.Rules[0].ResourceNames).To(Equal([]string{"db-identity", "web-identity"}))
+		Expect(role.Rules[1].Resources).To(Equal([]string{"certificates"}))
+		Expect(role.Rules[1].ResourceNames).To(Equal([]string{"db-identity", "web-identity"}))
+		Expect(role.Rules[2].Resources).To(Equal([]string{"certificaterequests"}))
+
+		binding := &rbacv1.RoleBinding{}
+		Expect(c.Get(ctx, roleNN, binding)).To(Succeed())
+		Expect(binding.RoleRef.Name).To(Equal(role.Name))
+		Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      agent.Name,
+			Namespace: agent.Namespace,
+		}))
+
+		By("deleting the claims")
+		Expect(c.Delete(ctx, newClaim("web", ""))).To(Succeed())
+		Expect(c.Delete(ctx, newClaim("db", ""))).To(Succeed())
+		_, err = r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(c.Get(ctx, roleNN, &rbacv1.Role{}))).To(BeTrue())
+		Expect(errors.IsNotFound(c.Get(ctx, roleNN, &rbacv1.RoleBinding{}))).To(BeTrue())
+	})
+
+	It("should leave out CertificateRequests without CSI volumes", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(newClaim("web", "web-identity")).
+			Build()
+		r := &AgentAccessReconciler{Client: c, ServiceAccount: agent}
+
+		_, err := r.Reconcile(ctx, request)
+		Expect(err).NotTo(HaveOccurred())
+		role := &rbacv1.Role{}
+		Expect(c.Get(ctx, roleNN, role)).To(Succeed())
+		Expect(role.Rules).To(HaveLen(2))
+	})
+})
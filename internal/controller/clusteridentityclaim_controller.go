@@ -0,0 +1,232 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// errClaimNotManaged is returned when the namespace already has an IdentityClaim
+// of the ClusterIdentityClaim's name that the ClusterIdentityClaim doesn't control.
+var errClaimNotManaged = errors.New("IdentityClaim already exists and is not managed by this ClusterIdentityClaim")
+
+// ClusterIdentityClaimReconciler reconciles a ClusterIdentityClaim object by
+// managing one IdentityClaim per selected namespace. Issuance itself is left to
+// the IdentityClaimReconciler, so both kinds produce identical identities.
+type ClusterIdentityClaimReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=identity.cluster.local,resources=clusteridentityclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups=identity.cluster.local,resources=clusteridentityclaims/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=identity.cluster.local,resources=clusteridentityclaims/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile implements the reconciliation loop for ClusterIdentityClaim resources
+func (r *ClusterIdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	cic := &identityv1alpha1.ClusterIdentityClaim{}
+	if err := r.Get(ctx, req.NamespacedName, cic); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("ClusterIdentityClaim not found, ignoring")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// The garbage collector removes the IdentityClaims through their owner references.
+	if !cic.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&cic.Spec.NamespaceSelector)
+	if err != nil {
+		r.setCondition(cic, metav1.ConditionFalse, "SelectorError",
+			fmt.Sprintf("Invalid namespaceSelector: %v", err))
+		return ctrl.Result{}, r.Status().Update(ctx, cic)
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	selected := make(map[string]bool, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		if ns.Status.Phase != corev1.NamespaceTerminating {
+			selected[ns.Name] = true
+		}
+	}
+
+	// Clean up IdentityClaims in namespaces that stopped matching
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.MatchingLabels{identityv1alpha1.ClusterIdentityClaimLabel: cic.Name}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list IdentityClaims: %w", err)
+	}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if selected[claim.Namespace] || !metav1.IsControlledBy(claim, cic) {
+			continue
+		}
+		log.Info("Deleting IdentityClaim of unselected namespace", "namespace", claim.Namespace)
+		if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	statuses := make([]identityv1alpha1.ClusterIdentityClaimNamespaceStatus, 0, len(selected))
+	var ready int32
+	for _, ns := range namespaces.Items {
+		if !selected[ns.Name] {
+			continue
+		}
+		status, err := r.reconcileNamespace(ctx, cic, ns.Name)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if status.Phase == identityv1alpha1.PhaseReady {
+			ready++
+		}
+		statuses = append(statuses, status)
+	}
+
+	cic.Status.Namespaces = statuses
+	cic.Status.TotalNamespaces = int32(len(statuses))
+	cic.Status.ReadyNamespaces = ready
+	switch {
+	case len(statuses) == 0:
+		r.setCondition(cic, metav1.ConditionFalse, "NoNamespaces", "No namespaces match namespaceSelector")
+	case int(ready) == len(statuses):
+		r.setCondition(cic, metav1.ConditionTrue, "Ready", "Identity is ready in every selected namespace")
+	default:
+		r.setCondition(cic, metav1.ConditionFalse, "NamespacesNotReady",
+			fmt.Sprintf("Identity is ready in %d of %d namespaces", ready, len(statuses)))
+	}
+	if err := r.Status().Update(ctx, cic); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileNamespace creates or updates the IdentityClaim of cic in namespace
+// and returns its state. Errors that retrying won't fix are reported in the
+// returned status instead.
+func (r *ClusterIdentityClaimReconciler) reconcileNamespace(ctx context.Context, cic *identityv1alpha1.ClusterIdentityClaim, namespace string) (identityv1alpha1.ClusterIdentityClaimNamespaceStatus, error) {
+	status := identityv1alpha1.ClusterIdentityClaimNamespaceStatus{Namespace: namespace}
+
+	claim := &identityv1alpha1.IdentityClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: cic.Name, Namespace: namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, claim, func() error {
+		if !claim.CreationTimestamp.IsZero() && !metav1.IsControlledBy(claim, cic) {
+			return errClaimNotManaged
+		}
+		if claim.Labels == nil {
+			claim.Labels = map[string]string{}
+		}
+		claim.Labels[identityv1alpha1.ClusterIdentityClaimLabel] = cic.Name
+		cic.Spec.IdentityClaimSpec.DeepCopyInto(&claim.Spec)
+		return controllerutil.SetControllerReference(cic, claim, r.Scheme)
+	})
+	switch {
+	case errors.Is(err, errClaimNotManaged):
+		status.Phase = identityv1alpha1.PhaseFailed
+		status.Message = fmt.Sprintf("IdentityClaim %s/%s already exists and is not managed by this ClusterIdentityClaim",
+			namespace, cic.Name)
+		return status, nil
+	case apierrors.IsInvalid(err) || apierrors.IsForbidden(err):
+		// Rejected by the API server or the admission webhook
+		status.Phase = identityv1alpha1.PhaseFailed
+		status.Message = err.Error()
+		return status, nil
+	case err != nil:
+		return status, fmt.Errorf("failed to reconcile IdentityClaim in namespace %s: %w", namespace, err)
+	}
+
+	status.Phase = claim.Status.Phase
+	status.SpiffeID = claim.Status.SpiffeID
+	status.SecretName = claim.Status.SecretName
+	status.ExpiresAt = claim.Status.ExpiresAt
+	if status.Phase != identityv1alpha1.PhaseReady {
+		if cond := meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionReady); cond != nil {
+			status.Message = cond.Message
+		}
+	}
+	return status, nil
+}
+
+// setCondition updates or adds the Ready condition of the ClusterIdentityClaim
+func (r *ClusterIdentityClaimReconciler) setCondition(cic *identityv1alpha1.ClusterIdentityClaim, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cic.Status.Conditions, metav1.Condition{
+		Type:               identityv1alpha1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: cic.Generation,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	})
+}
+
+// findClaimsForNamespace maps a Namespace to every ClusterIdentityClaim, since a
+// label change can add the namespace to or remove it from any of them.
+func (r *ClusterIdentityClaimReconciler) findClaimsForNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	cics := &identityv1alpha1.ClusterIdentityClaimList{}
+	if err := r.List(ctx, cics); err != nil {
+		log.Error(err, "failed to list ClusterIdentityClaims for namespace", "namespace", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(cics.Items))
+	for _, cic := range cics.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cic.Name}})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterIdentityClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&identityv1alpha1.ClusterIdentityClaim{}).
+		Owns(&identityv1alpha1.IdentityClaim{}).
+		Watches(&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.findClaimsForNamespace),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Named("clusteridentityclaim").
+		Complete(r)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+var _ = Describe("ClusterIdentityClaim Controller", func() {
+	Context("When namespaces match the namespaceSelector", func() {
+		const resourceName = "log-agent"
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: resourceName}
+		// envtest has no namespace controller, so namespaces can't be deleted
+		// between specs; every namespace here is only used by this context.
+		selectedNamespaces := []string{"cic-team-a", "cic-team-b"}
+		const unselectedNamespace = "cic-team-c"
+
+		BeforeEach(func() {
+			for _, name := range append(selectedNamespaces, unselectedNamespace) {
+				ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name}, ns); errors.IsNotFound(err) {
+					Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+				}
+				ns.Labels = map[string]string{"logging": "enabled"}
+				if name == unselectedNamespace {
+					ns.Labels = nil
+				}
+				Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+			}
+
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.ClusterIdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+				Spec: identityv1alpha1.ClusterIdentityClaimSpec{
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"logging": "enabled"}},
+					IdentityClaimSpec: identityv1alpha1.IdentityClaimSpec{
+						Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "log-agent"}},
+						TTL:      metav1.Duration{Duration: 1 * time.Hour},
+					},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &identityv1alpha1.ClusterIdentityClaim{}
+			if err := k8sClient.Get(ctx, nn, resource); err == nil {
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			}
+			// envtest doesn't run the garbage collector either
+			for _, ns := range append(selectedNamespaces, unselectedNamespace) {
+				claim := &identityv1alpha1.IdentityClaim{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: resourceName, Namespace: ns}, claim); err == nil {
+					Expect(k8sClient.Delete(ctx, claim)).To(Succeed())
+				}
+			}
+		})
+
+		It("should create an IdentityClaim in every selected namespace", func() {
+			controllerReconciler := &ClusterIdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			cic := &identityv1alpha1.ClusterIdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, cic)).To(Succeed())
+			for _, ns := range selectedNamespaces {
+				claim := &identityv1alpha1.IdentityClaim{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName, Namespace: ns}, claim)).To(Succeed())
+				Expect(metav1.IsControlledBy(claim, cic)).To(BeTrue())
+				Expect(claim.Labels).To(HaveKeyWithValue(identityv1alpha1.ClusterIdentityClaimLabel, resourceName))
+				Expect(claim.Spec.Selector.MatchLabels).To(HaveKeyWithValue("app", "log-agent"))
+			}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: resourceName, Namespace: unselectedNamespace},
+				&identityv1alpha1.IdentityClaim{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(cic.Status.TotalNamespaces).To(Equal(int32(2)))
+			Expect(cic.Status.ReadyNamespaces).To(BeZero())
+			Expect(cic.Status.Namespaces).To(HaveLen(2))
+			cond := meta.FindStatusCondition(cic.Status.Conditions, identityv1alpha1.ConditionReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal("NamespacesNotReady"))
+		})
+
+		It("should delete the IdentityClaim of a namespace that stops matching", func() {
+			controllerReconciler := &ClusterIdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: selectedNamespaces[1]}, ns)).To(Succeed())
+			ns.Labels = nil
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: resourceName, Namespace: selectedNamespaces[1]},
+				&identityv1alpha1.IdentityClaim{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			cic := &identityv1alpha1.ClusterIdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, cic)).To(Succeed())
+			Expect(cic.Status.TotalNamespaces).To(Equal(int32(1)))
+		})
+
+		It("should report an existing IdentityClaim it doesn't manage", func() {
+			controllerReconciler := &ClusterIdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: selectedNamespaces[0]},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "something-else"}},
+					TTL:      metav1.Duration{Duration: 1 * time.Hour},
+				},
+			})).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName, Namespace: selectedNamespaces[0]}, claim)).To(Succeed())
+			Expect(claim.Spec.Selector.MatchLabels).To(HaveKeyWithValue("app", "something-else"))
+
+			cic := &identityv1alpha1.ClusterIdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, cic)).To(Succeed())
+			Expect(cic.Status.Namespaces).To(ContainElement(And(
+				HaveField("Namespace", selectedNamespaces[0]),
+				HaveField("Phase", identityv1alpha1.PhaseFailed),
+				HaveField("Message", ContainSubstring("not managed by this ClusterIdentityClaim")),
+			)))
+		})
+	})
+})
@@ -0,0 +1,155 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// deletionPolicy returns the claim's deletion policy, defaulting to Delete for
+// claims created before the field existed.
+func deletionPolicy(claim *identityv1alpha1.IdentityClaim) identityv1alpha1.DeletionPolicy {
+	if claim.Spec.DeletionPolicy == "" {
+		return identityv1alpha1.DeletionPolicyDelete
+	}
+	return claim.Spec.DeletionPolicy
+}
+
+// remainingGracePeriod returns how much longer a deleted claim keeps its
+// Certificate and Secret, or zero when it can be cleaned up now. The claim waits
+// while selected pods are running, until spec.deletionGracePeriod after its
+// deletion; the deadline is recorded in status.deletionDeadline.
+func (r *IdentityClaimReconciler) remainingGracePeriod(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (time.Duration, error) {
+	grace := claim.Spec.DeletionGracePeriod
+	if grace == nil || grace.Duration <= 0 || deletionPolicy(claim) == identityv1alpha1.DeletionPolicyOrphan {
+		return 0, nil
+	}
+	deadline := claim.DeletionTimestamp.Add(grace.Duration)
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
+	if err != nil {
+		// No pod can have been issued an identity for an invalid selector
+		return 0, nil
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods,
+		client.InNamespace(claim.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+	running := 0
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			running++
+		}
+	}
+	if running == 0 {
+		return 0, nil
+	}
+
+	if claim.Status.DeletionDeadline == nil {
+		claim.Status.DeletionDeadline = &metav1.Time{Time: deadline}
+		r.recordEvent(claim, nil, corev1.EventTypeNormal, "DeletionPending", "Delete",
+			"Keeping Certificate and Secret until %s while %d selected pod(s) are running",
+			deadline.UTC().Format(time.RFC3339), running)
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return 0, err
+		}
+	}
+	return remaining, nil
+}
+
+// issuedSecret returns the claim's identity Secret if cert-manager wrote it for
+// the claim's Certificate, or nil. Secrets are read through the API reader so
+// the operator doesn't cache every Secret in the cluster.
+func (r *IdentityClaimReconciler) issuedSecret(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (*corev1.Secret, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}
+	if err := reader.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if secret.Annotations[certmanagerv1.CertificateNameKey] != key.Name {
+		return nil, nil
+	}
+	return secret, nil
+}
+
+// deleteSecret deletes the claim's identity Secret and reports whether it did;
+// Secrets that don't exist or weren't issued for the claim's Certificate are
+// left alone. Callers must have checked that the claim controls that
+// Certificate. cert-manager only removes it along with the Certificate when it
+// runs with --enable-certificate-owner-ref.
+func (r *IdentityClaimReconciler) deleteSecret(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (bool, error) {
+	secret, err := r.issuedSecret(ctx, claim)
+	if err != nil || secret == nil {
+		return false, err
+	}
+	if err := r.Delete(ctx, secret); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return true, nil
+}
+
+// retainSecret detaches the claim's identity Secret from its Certificate, so
+// the garbage collector keeps it when the Certificate is deleted.
+func (r *IdentityClaimReconciler) retainSecret(ctx context.Context, claim *identityv1alpha1.IdentityClaim) error {
+	secret, err := r.issuedSecret(ctx, claim)
+	if err != nil || secret == nil {
+		return err
+	}
+	owners := slices.DeleteFunc(slices.Clone(secret.OwnerReferences), func(ref metav1.OwnerReference) bool {
+		return ref.Kind == "Certificate" && ref.Name == claim.Status.SecretName
+	})
+	if len(owners) == len(secret.OwnerReferences) {
+		return nil
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	secret.OwnerReferences = owners
+	return client.IgnoreNotFound(r.Patch(ctx, secret, patch))
+}
+
+// orphanCertificate removes the claim's owner reference from its Certificate,
+// so the garbage collector keeps it and cert-manager keeps renewing it.
+func (r *IdentityClaimReconciler) orphanCertificate(ctx context.Context, claim *identityv1alpha1.IdentityClaim, cert *certmanagerv1.Certificate) error {
+	patch := client.MergeFrom(cert.DeepCopy())
+	cert.OwnerReferences = slices.DeleteFunc(cert.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.UID == claim.UID
+	})
+	return client.IgnoreNotFound(r.Patch(ctx, cert, patch))
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// The controller suite's envtest doesn't install cert-manager's CRDs, so
+// deletions are reconciled against a fake client holding the Certificates.
+var _ = Describe("IdentityClaim deletion", func() {
+	const secretName = "payments-tls"
+	ctx := context.Background()
+	secretNN := types.NamespacedName{Name: secretName, Namespace: "default"}
+
+	// newClaim returns a claim named name using the payments-tls Secret name,
+	// being deleted with the default deletionPolicy.
+	newClaim := func(name, uid string) *identityv1alpha1.IdentityClaim {
+		return &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				UID:               types.UID(uid),
+				Finalizers:        []string{finalizerName},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector:   metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+				TTL:        metav1.Duration{Duration: 1 * time.Hour},
+				SecretName: secretName,
+			},
+			Status: identityv1alpha1.IdentityClaimStatus{
+				Phase:      identityv1alpha1.PhaseReady,
+				SecretName: secretName,
+			},
+		}
+	}
+
+	// newClient returns a client holding claim and the Certificate owner
+	// controls, with the Secret cert-manager issued for it.
+	newClient := func(claim, owner *identityv1alpha1.IdentityClaim) client.Client {
+		cert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+		}
+		Expect(controllerutil.SetControllerReference(owner, cert, scheme.Scheme)).To(Succeed())
+		objects := []client.Object{
+			claim, cert,
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        secretName,
+				Namespace:   "default",
+				Annotations: map[string]string{certmanagerv1.CertificateNameKey: secretName},
+			}},
+		}
+		if owner != claim {
+			objects = append(objects, owner)
+		}
+		return fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(claim).
+			Build()
+	}
+
+	It("should delete the Certificate and Secret of the claim", func() {
+		claim := newClaim("payments", "00000000-0000-0000-0000-000000000030")
+		c := newClient(claim, claim)
+		r := &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(claim), &identityv1alpha1.IdentityClaim{}))).To(BeTrue())
+		Expect(errors.IsNotFound(c.Get(ctx, secretNN, &certmanagerv1.Certificate{}))).To(BeTrue())
+		Expect(errors.IsNotFound(c.Get(ctx, secretNN, &corev1.Secret{}))).To(BeTrue())
+	})
+
+	It("should detach the Secret from its Certificate with Retain", func() {
+		claim := newClaim("payments", "00000000-0000-0000-0000-000000000034")
+		claim.Spec.DeletionPolicy = identityv1alpha1.DeletionPolicyRetain
+		c := newClient(claim, claim)
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, secretNN, secret)).To(Succeed())
+		secret.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "cert-manager.io/v1",
+			Kind:       "Certificate",
+			Name:       secretName,
+			UID:        "00000000-0000-0000-0000-000000000035",
+		}}
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		r := &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(errors.IsNotFound(c.Get(ctx, secretNN, &certmanagerv1.Certificate{}))).To(BeTrue())
+		Expect(c.Get(ctx, secretNN, secret)).To(Succeed())
+		Expect(secret.OwnerReferences).To(BeEmpty())
+	})
+
+	It("should leave another claim's Certificate and Secret alone", func() {
+		owner := newClaim("payments", "00000000-0000-0000-0000-000000000031")
+		owner.DeletionTimestamp = nil
+		owner.Finalizers = nil
+		claim := newClaim("payments-copy", "00000000-0000-0000-0000-000000000032")
+		claim.Status.Phase = identityv1alpha1.PhaseFailed
+		claim.Status.Conditions = []metav1.Condition{{
+			Type:   identityv1alpha1.ConditionCertificateIssued,
+			Status: metav1.ConditionFalse,
+			Reason: "SecretConflict",
+		}}
+		c := newClient(claim, owner)
+		r := &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(claim), &identityv1alpha1.IdentityClaim{}))).To(BeTrue())
+		Expect(c.Get(ctx, secretNN, &certmanagerv1.Certificate{})).To(Succeed())
+		Expect(c.Get(ctx, secretNN, &corev1.Secret{})).To(Succeed())
+
+		By("a conflicting claim whose condition was overwritten")
+		claim = newClaim("payments-other", "00000000-0000-0000-0000-000000000033")
+		c = newClient(claim, owner)
+		r = &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+		_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, secretNN, &corev1.Secret{})).To(Succeed())
+	})
+})
@@ -0,0 +1,116 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// defaultClusterDomain is the DNS suffix of Services when none is configured.
+const defaultClusterDomain = "cluster.local"
+
+// resolveSANs returns the DNS and IP SANs for the claim's certificate from
+// spec.dnsNames. With fromServices, every Service in the namespace whose
+// selector matches at least one of the claim's pods contributes its short and
+// fully qualified names and its cluster IPs.
+func (r *IdentityClaimReconciler) resolveSANs(ctx context.Context, claim *identityv1alpha1.IdentityClaim, pods []corev1.Pod) ([]string, []string, error) {
+	spec := claim.Spec.DNSNames
+	if spec == nil {
+		return nil, nil, nil
+	}
+
+	dnsNames := sets.New(spec.Names...)
+	ipAddresses := sets.New[string]()
+	if !spec.FromServices {
+		return sets.List(dnsNames), nil, nil
+	}
+
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services, client.InNamespace(claim.Namespace)); err != nil {
+		return nil, nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	clusterDomain := r.ClusterDomain
+	if clusterDomain == "" {
+		clusterDomain = defaultClusterDomain
+	}
+	for i := range services.Items {
+		svc := &services.Items[i]
+		if !serviceFrontsPods(svc, pods) {
+			continue
+		}
+		dnsNames.Insert(
+			svc.Name,
+			fmt.Sprintf("%s.%s", svc.Name, svc.Namespace),
+			fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace),
+			fmt.Sprintf("%s.%s.svc.%s", svc.Name, svc.Namespace, clusterDomain),
+		)
+		for _, ip := range svc.Spec.ClusterIPs {
+			if net.ParseIP(ip) != nil {
+				ipAddresses.Insert(ip)
+			}
+		}
+	}
+	return sets.List(dnsNames), sets.List(ipAddresses), nil
+}
+
+// serviceFrontsPods reports whether the Service's selector matches any of the pods.
+// Services without a selector route to manually managed endpoints and are ignored.
+func serviceFrontsPods(svc *corev1.Service, pods []corev1.Pod) bool {
+	if len(svc.Spec.Selector) == 0 {
+		return false
+	}
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	return slices.ContainsFunc(pods, func(pod corev1.Pod) bool {
+		return selector.Matches(labels.Set(pod.Labels))
+	})
+}
+
+// findClaimsForService maps a Service to every IdentityClaim in its namespace
+// that derives DNS names from Services.
+func (r *IdentityClaimReconciler) findClaimsForService(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list IdentityClaims for service", "service", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, claim := range claims.Items {
+		if claim.Spec.DNSNames == nil || !claim.Spec.DNSNames.FromServices {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+		})
+	}
+	return requests
+}
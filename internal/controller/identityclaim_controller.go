@@ -19,6 +19,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
@@ -27,18 +29,27 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+	"github.com/osagberg/identity-claim-operator/internal/spiffeid"
 )
 
 const (
 	finalizerName = "identity.cluster.local/finalizer"
-	trustDomain   = "cluster.local"
 )
 
 const (
@@ -52,13 +63,44 @@ type IdentityClaimReconciler struct {
 	Scheme            *runtime.Scheme
 	DefaultIssuerName string
 	DefaultIssuerKind string
+	// TrustDomain is the SPIFFE trust domain of issued identities.
+	// Defaults to cluster.local when empty.
+	TrustDomain string
+	// ClusterDomain is the DNS suffix used for Service names in certificates.
+	// Defaults to cluster.local when empty.
+	ClusterDomain string
+	// SpiffeIDPathTemplate renders the SPIFFE ID path of claims that don't set
+	// spec.spiffeIDPath. Defaults to spiffeid.DefaultPathTemplate when empty.
+	SpiffeIDPathTemplate string
+	// Recorder emits Kubernetes Events for phase transitions and failures.
+	// SetupWithManager provides one from the manager when unset.
+	Recorder events.EventRecorder
+	// RefuseOverlappingClaims stops issuance for a claim that selects pods an
+	// older claim in the same namespace already selects.
+	RefuseOverlappingClaims bool
+	// MaxClaimsPerNamespace limits the IdentityClaims of a namespace unless an
+	// IdentityPolicy or namespace annotation overrides it. 0 means unlimited.
+	MaxClaimsPerNamespace int32
+	// MaxIssuancesPerHour limits the certificates issued in a namespace per hour
+	// unless an IdentityPolicy or namespace annotation overrides it. 0 means unlimited.
+	MaxIssuancesPerHour int32
+	// APIReader reads Secrets directly from the API server, so the manager
+	// doesn't cache every Secret in the cluster. SetupWithManager provides one
+	// from the manager when unset; the client is used otherwise.
+	APIReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=identity.cluster.local,resources=identityclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=identity.cluster.local,resources=identityclaims/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=identity.cluster.local,resources=identityclaims/finalizers,verbs=update
+// +kubebuilder:rbac:groups=identity.cluster.local,resources=identitypolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates/status,verbs=update
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;patch;delete
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 // Reconcile implements the reconciliation loop for IdentityClaim resources
 func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -88,11 +130,17 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// Freeze the identity as it is while the claim is suspended
+	if claim.Spec.Suspend {
+		return r.reconcileSuspended(ctx, claim)
+	}
+	r.resume(ctx, claim)
+
 	// Initialize status if needed
 	if claim.Status.Phase == "" {
-		claim.Status.Phase = identityv1alpha1.PhasePending
-		claim.Status.SpiffeID = r.generateSpiffeID(claim)
-		claim.Status.SecretName = fmt.Sprintf("%s-identity", claim.Name)
+		claim.Status.TrustDomain = r.trustDomain()
+		claim.Status.SecretName = claim.IdentitySecretName()
+		r.setPhase(claim, identityv1alpha1.PhasePending)
 		if err := r.Status().Update(ctx, claim); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -105,9 +153,20 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		ttl = time.Hour
 	}
 	if ttl < minTTL || ttl > maxTTL {
-		claim.Status.Phase = identityv1alpha1.PhaseFailed
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
 		r.setCondition(claim, identityv1alpha1.ConditionReady, metav1.ConditionFalse,
 			"InvalidTTL", fmt.Sprintf("TTL must be between %s and %s, got %s", minTTL, maxTTL, ttl))
+		recordReason("InvalidTTL")
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if err := validateRenewBefore(claim, ttl); err != nil {
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
+		r.setCondition(claim, identityv1alpha1.ConditionReady, metav1.ConditionFalse,
+			"InvalidRenewBefore", err.Error())
+		recordReason("InvalidRenewBefore")
 		if err := r.Status().Update(ctx, claim); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -115,11 +174,12 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	// Verify pods matching selector exist
-	podsFound, err := r.verifyMatchingPods(ctx, claim)
+	pods, err := r.verifyMatchingPods(ctx, claim)
 	if err != nil {
-		claim.Status.Phase = identityv1alpha1.PhaseFailed
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
 		r.setCondition(claim, identityv1alpha1.ConditionPodsVerified, metav1.ConditionFalse,
 			"SelectorError", err.Error())
+		recordReason("SelectorError")
 		if updateErr := r.Status().Update(ctx, claim); updateErr != nil {
 			log.Error(updateErr, "failed to update status after selector error")
 		}
@@ -127,27 +187,151 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		// Retrying won't fix an invalid selector; the user must update the spec.
 		return ctrl.Result{}, nil
 	}
-	if podsFound == 0 {
+
+	// Render the SPIFFE ID. Claims issued under a different trust domain or path
+	// are migrated: the Certificate is updated with the new URI below, which makes
+	// cert-manager re-issue it; the previous certificate stays valid until it expires.
+	// Without pods, templates using pod attributes can't be rendered yet.
+	spiffeID, err := r.generateSpiffeID(claim, pods)
+	switch {
+	case err != nil && len(pods) > 0:
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
+		r.setCondition(claim, identityv1alpha1.ConditionReady, metav1.ConditionFalse,
+			"InvalidSpiffeID", err.Error())
+		recordReason("InvalidSpiffeID")
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Fixing the template or the pods triggers a new reconcile through the watches.
+		return ctrl.Result{}, nil
+	case err == nil && claim.Status.SpiffeID != spiffeID:
+		if claim.Status.SpiffeID != "" {
+			log.Info("SPIFFE ID changed, re-issuing", "old", claim.Status.SpiffeID, "new", spiffeID)
+			r.recordEvent(claim, nil, corev1.EventTypeNormal, "SpiffeIDChanged", "Reconcile",
+				"SPIFFE ID changed from %s to %s, re-issuing certificate", claim.Status.SpiffeID, spiffeID)
+		}
+		claim.Status.SpiffeID = spiffeID
+		claim.Status.TrustDomain = r.trustDomain()
+	}
+
+	if len(pods) == 0 {
+		meta.RemoveStatusCondition(&claim.Status.Conditions, identityv1alpha1.ConditionSelectorConflict)
 		r.setCondition(claim, identityv1alpha1.ConditionPodsVerified, metav1.ConditionFalse, "NoPods",
 			"No pods matching selector found")
+		recordReason("NoPods")
 		if err := r.Status().Update(ctx, claim); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		// No requeue: the Pod watch enqueues this claim as soon as a matching pod appears.
+		return ctrl.Result{}, nil
 	}
 	r.setCondition(claim, identityv1alpha1.ConditionPodsVerified, metav1.ConditionTrue, "PodsFound",
-		fmt.Sprintf("Found %d matching pod(s)", podsFound))
+		fmt.Sprintf("Found %d matching pod(s)", len(pods)))
 
-	// Create or update the Certificate resource
-	if err := r.reconcileCertificate(ctx, claim); err != nil {
-		claim.Status.Phase = identityv1alpha1.PhaseFailed
+	// Enforce IdentityPolicies; claims admitted before a policy existed are caught here
+	violation, err := r.checkPolicies(ctx, claim, spiffeID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if violation != nil {
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
+		r.setCondition(claim, identityv1alpha1.ConditionPolicyViolation, metav1.ConditionTrue,
+			"DeniedByPolicy", violation.Error())
+		r.setCondition(claim, identityv1alpha1.ConditionReady, metav1.ConditionFalse,
+			"PolicyViolation", violation.Error())
+		recordReason("PolicyViolation")
+		// Stop cert-manager from renewing what the policy now forbids
+		if err := r.deleteDeniedCertificate(ctx, claim, violation); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, err
+		}
+		// The IdentityPolicy watch triggers a new reconcile when policies change.
+		return ctrl.Result{}, nil
+	}
+	meta.RemoveStatusCondition(&claim.Status.Conditions, identityv1alpha1.ConditionPolicyViolation)
+
+	// A pod selected by several claims would carry several SPIFFE IDs
+	overlapping, err := r.findOverlappingClaims(ctx, claim, pods)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(overlapping) == 0 {
+		meta.RemoveStatusCondition(&claim.Status.Conditions, identityv1alpha1.ConditionSelectorConflict)
+	} else {
+		names := make([]string, 0, len(overlapping))
+		younger := false
+		for i := range overlapping {
+			names = append(names, overlapping[i].Name)
+			younger = younger || isOlderClaim(&overlapping[i], claim)
+		}
+		r.setCondition(claim, identityv1alpha1.ConditionSelectorConflict, metav1.ConditionTrue, "OverlappingSelectors",
+			fmt.Sprintf("Selects the same pods as IdentityClaim(s) %s", strings.Join(names, ", ")))
+		if younger && r.RefuseOverlappingClaims {
+			r.setPhase(claim, identityv1alpha1.PhaseFailed)
+			r.setCondition(claim, identityv1alpha1.ConditionReady, metav1.ConditionFalse, "SelectorConflict",
+				"Not issued because an older IdentityClaim selects the same pods")
+			recordReason("SelectorConflict")
+			if err := r.Status().Update(ctx, claim); err != nil {
+				return ctrl.Result{}, err
+			}
+			// Pod and claim watches trigger a new reconcile once the overlap is resolved.
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// Never point cert-manager at a Secret or Certificate that belongs to someone else
+	conflict, err := r.findSecretConflict(ctx, claim)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if conflict != "" {
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
 		r.setCondition(claim, identityv1alpha1.ConditionCertificateIssued, metav1.ConditionFalse,
-			"CertificateFailed", err.Error())
-		if statusErr := r.Status().Update(ctx, claim); statusErr != nil {
-			log.Error(statusErr, "Failed to update status")
+			"SecretConflict", conflict)
+		recordReason("SecretConflict")
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, err
 		}
+		// Secrets aren't watched; check again in case the conflicting object was removed.
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	spec, err := r.certificateSpec(ctx, claim, pods)
+	if err != nil {
+		return r.certificateFailed(ctx, claim, err)
+	}
+
+	// Keep one namespace from flooding the CA
+	issuing, err := r.issuesCertificate(ctx, claim, spec)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	quotaViolation, err := r.checkQuota(ctx, claim, issuing)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if quotaViolation != nil {
+		r.setPhase(claim, identityv1alpha1.PhaseFailed)
+		r.setCondition(claim, identityv1alpha1.ConditionQuotaExceeded, metav1.ConditionTrue,
+			quotaViolation.reason, quotaViolation.message)
+		r.setCondition(claim, identityv1alpha1.ConditionReady, metav1.ConditionFalse,
+			"QuotaExceeded", quotaViolation.message)
+		recordReason("QuotaExceeded")
+		quotaExceeded.WithLabelValues(claim.Namespace, quotaViolation.quota).Inc()
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Deleted claims and changed limits trigger a new reconcile through the watches.
+		return ctrl.Result{RequeueAfter: quotaViolation.retryAfter}, nil
+	}
+	meta.RemoveStatusCondition(&claim.Status.Conditions, identityv1alpha1.ConditionQuotaExceeded)
+
+	// Create or update the Certificate resource
+	if err := r.reconcileCertificate(ctx, claim, spec); err != nil {
+		return r.certificateFailed(ctx, claim, err)
+	}
 
 	// Check certificate status
 	cert := &certmanagerv1.Certificate{}
@@ -157,7 +341,7 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{}, err
 		}
 		// Certificate not found yet, requeue
-		claim.Status.Phase = identityv1alpha1.PhaseIssuing
+		r.setPhase(claim, identityv1alpha1.PhaseIssuing)
 		r.setCondition(claim, identityv1alpha1.ConditionCertificateIssued, metav1.ConditionFalse,
 			"Issuing", "Waiting for certificate to be created")
 		if err := r.Status().Update(ctx, claim); err != nil {
@@ -176,7 +360,7 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	if !certReady {
-		claim.Status.Phase = identityv1alpha1.PhaseIssuing
+		r.setPhase(claim, identityv1alpha1.PhaseIssuing)
 		r.setCondition(claim, identityv1alpha1.ConditionCertificateIssued, metav1.ConditionFalse,
 			"Issuing", "Certificate is being issued")
 		if err := r.Status().Update(ctx, claim); err != nil {
@@ -185,8 +369,36 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
+	// Re-issue with a new private key when the rotate-requested-at annotation asks for it
+	if pendingRotation(ctx, claim) {
+		rotated, err := r.rotate(ctx, claim, cert)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !rotated {
+			// Wait for the issuance in progress, which may use the old key
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		// The certificate in the Secret is about to be replaced
+		r.setPhase(claim, identityv1alpha1.PhaseIssuing)
+		r.setCondition(claim, identityv1alpha1.ConditionCertificateIssued, metav1.ConditionFalse,
+			"Rotating", "Certificate is being re-issued with a new private key")
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	if claim.Status.Phase == identityv1alpha1.PhaseIssuing && certificateIssuing(cert) {
+		// A triggered re-issuance leaves the Certificate Ready until it completes
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
 	// Certificate is ready
-	claim.Status.Phase = identityv1alpha1.PhaseReady
+	becameReady := claim.Status.Phase != identityv1alpha1.PhaseReady
+	if !meta.IsStatusConditionTrue(claim.Status.Conditions, identityv1alpha1.ConditionReady) {
+		recordIssuance(claim)
+	}
+	r.setPhase(claim, identityv1alpha1.PhaseReady)
 	if cert.Status.NotAfter != nil {
 		claim.Status.ExpiresAt = cert.Status.NotAfter
 	}
@@ -198,10 +410,14 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	if err := r.Status().Update(ctx, claim); err != nil {
 		return ctrl.Result{}, err
 	}
+	if becameReady {
+		r.recordEvent(cert, claim, corev1.EventTypeNormal, "IdentityReady", "Reconcile",
+			"Certificate backs identity %s of IdentityClaim %s", claim.Status.SpiffeID, claim.Name)
+	}
 
-	// Requeue before certificate expires to trigger renewal
+	// Requeue when cert-manager renews the certificate, so the new expiry is picked up
 	if claim.Status.ExpiresAt != nil {
-		renewAt := claim.Status.ExpiresAt.Add(-10 * time.Minute)
+		renewAt := claim.Status.ExpiresAt.Add(-renewBefore(claim, ttl))
 		if time.Now().Before(renewAt) {
 			return ctrl.Result{RequeueAfter: time.Until(renewAt)}, nil
 		}
@@ -210,22 +426,68 @@ func (r *IdentityClaimReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return ctrl.Result{RequeueAfter: 30 * time.Minute}, nil
 }
 
-// reconcileDelete handles cleanup when an IdentityClaim is deleted
+// reconcileDelete handles cleanup when an IdentityClaim is deleted, keeping or
+// removing the Certificate and Secret as spec.deletionPolicy says.
 func (r *IdentityClaimReconciler) reconcileDelete(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
-	// Delete the associated Certificate
+	// Give running pods time to move to another identity
+	remaining, err := r.remainingGracePeriod(ctx, claim)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if remaining > 0 {
+		log.Info("Waiting for selected pods before deleting identity", "deadline", claim.Status.DeletionDeadline)
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	policy := deletionPolicy(claim)
 	cert := &certmanagerv1.Certificate{}
 	certName := claim.Status.SecretName
-	if certName != "" {
-		if err := r.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: certName}, cert); err == nil {
-			log.Info("Deleting Certificate", "name", certName)
-			if err := r.Delete(ctx, cert); err != nil && !apierrors.IsNotFound(err) {
-				return ctrl.Result{}, err
+	// A claim stopped by a SecretConflict never issued under its Secret name;
+	// the Certificate and Secret there belong to someone else.
+	issued := meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionCertificateIssued)
+	if certName != "" && (issued == nil || issued.Reason != "SecretConflict") {
+		err := r.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: certName}, cert)
+		if err == nil && metav1.IsControlledBy(cert, claim) {
+			switch policy {
+			case identityv1alpha1.DeletionPolicyOrphan:
+				log.Info("Orphaning Certificate", "name", certName)
+				if err := r.orphanCertificate(ctx, claim, cert); err != nil {
+					return ctrl.Result{}, err
+				}
+				r.recordEvent(cert, claim, corev1.EventTypeNormal, "Orphaned", "Delete",
+					"Certificate kept because IdentityClaim %s was deleted with deletionPolicy Orphan", claim.Name)
+			default:
+				if policy == identityv1alpha1.DeletionPolicyRetain {
+					if err := r.retainSecret(ctx, claim); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
+				log.Info("Deleting Certificate", "name", certName)
+				if err := r.Delete(ctx, cert); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, err
+				}
+				r.recordEvent(cert, claim, corev1.EventTypeNormal, "Deleted", "Delete",
+					"Certificate deleted because IdentityClaim %s was deleted", claim.Name)
+				// Only a Secret issued for the claim's own Certificate is revoked
+				if policy == identityv1alpha1.DeletionPolicyDelete {
+					if _, err := r.deleteSecret(ctx, claim); err != nil {
+						return ctrl.Result{}, err
+					}
+				}
 			}
 		}
 	}
 
+	if policy == identityv1alpha1.DeletionPolicyDelete {
+		r.recordEvent(claim, nil, corev1.EventTypeNormal, "Deleted", "Delete",
+			"IdentityClaim deleted, identity %s revoked", claim.Status.SpiffeID)
+	} else {
+		r.recordEvent(claim, nil, corev1.EventTypeNormal, "Deleted", "Delete",
+			"IdentityClaim deleted, Secret %s kept by deletionPolicy %s", certName, policy)
+	}
+
 	// Remove finalizer
 	controllerutil.RemoveFinalizer(claim, finalizerName)
 	if err := r.Update(ctx, claim); err != nil {
@@ -235,38 +497,85 @@ func (r *IdentityClaimReconciler) reconcileDelete(ctx context.Context, claim *id
 	return ctrl.Result{}, nil
 }
 
-// generateSpiffeID creates the SPIFFE ID for the claim
-func (r *IdentityClaimReconciler) generateSpiffeID(claim *identityv1alpha1.IdentityClaim) string {
-	return fmt.Sprintf("spiffe://%s/ns/%s/ic/%s", trustDomain, claim.Namespace, claim.Name)
+// generateSpiffeID renders the SPIFFE ID for the claim from spec.spiffeIDPath,
+// or the cluster-wide template when unset. Pod attributes are only exposed when
+// every selected pod agrees on them, so the result doesn't depend on which pod
+// happens to be listed first.
+func (r *IdentityClaimReconciler) generateSpiffeID(claim *identityv1alpha1.IdentityClaim, pods []corev1.Pod) (string, error) {
+	text := claim.Spec.SpiffeIDPath
+	if text == "" {
+		text = r.SpiffeIDPathTemplate
+	}
+	if text == "" {
+		text = spiffeid.DefaultPathTemplate
+	}
+	tmpl, err := spiffeid.ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := spiffeid.TemplateData{
+		Namespace: claim.Namespace,
+		Name:      claim.Name,
+		Labels:    map[string]string{},
+	}
+	serviceAccounts := sets.New[string]()
+	for i, pod := range pods {
+		serviceAccounts.Insert(pod.Spec.ServiceAccountName)
+		if i == 0 {
+			maps.Copy(data.Labels, pod.Labels)
+			continue
+		}
+		for key, value := range data.Labels {
+			if pod.Labels[key] != value {
+				delete(data.Labels, key)
+			}
+		}
+	}
+	if serviceAccounts.Len() == 1 {
+		data.ServiceAccount = serviceAccounts.UnsortedList()[0]
+	}
+
+	path, err := tmpl.Render(data)
+	if err != nil {
+		if serviceAccounts.Len() > 1 {
+			return "", fmt.Errorf("%w (selected pods use different service accounts: %s)",
+				err, strings.Join(sets.List(serviceAccounts), ", "))
+		}
+		return "", err
+	}
+	return fmt.Sprintf("spiffe://%s%s", r.trustDomain(), path), nil
 }
 
-// verifyMatchingPods checks if pods matching the selector exist
-func (r *IdentityClaimReconciler) verifyMatchingPods(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (int, error) {
+// trustDomain returns the configured trust domain, falling back to the default.
+func (r *IdentityClaimReconciler) trustDomain() string {
+	if r.TrustDomain == "" {
+		return spiffeid.DefaultTrustDomain
+	}
+	return r.TrustDomain
+}
+
+// verifyMatchingPods returns the pods matching the claim's selector
+func (r *IdentityClaimReconciler) verifyMatchingPods(ctx context.Context, claim *identityv1alpha1.IdentityClaim) ([]corev1.Pod, error) {
 	selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
 	if err != nil {
-		return 0, fmt.Errorf("invalid selector: %w", err)
+		return nil, fmt.Errorf("invalid selector: %w", err)
 	}
 
 	podList := &corev1.PodList{}
 	if err := r.List(ctx, podList,
 		client.InNamespace(claim.Namespace),
 		client.MatchingLabelsSelector{Selector: selector}); err != nil {
-		return 0, fmt.Errorf("failed to list pods: %w", err)
+		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
 
-	return len(podList.Items), nil
+	return podList.Items, nil
 }
 
 // reconcileCertificate creates or updates the cert-manager Certificate
-func (r *IdentityClaimReconciler) reconcileCertificate(ctx context.Context, claim *identityv1alpha1.IdentityClaim) error {
+func (r *IdentityClaimReconciler) reconcileCertificate(ctx context.Context, claim *identityv1alpha1.IdentityClaim,
+	spec certmanagerv1.CertificateSpec) error {
 	certName := claim.Status.SecretName
-
-	// Calculate duration from TTL
-	duration := claim.Spec.TTL.Duration
-	if duration == 0 {
-		duration = time.Hour // default 1h
-	}
-
 	cert := &certmanagerv1.Certificate{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      certName,
@@ -274,34 +583,119 @@ func (r *IdentityClaimReconciler) reconcileCertificate(ctx context.Context, clai
 		},
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cert, func() error {
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, cert, func() error {
 		// Set owner reference for garbage collection
 		if err := controllerutil.SetControllerReference(claim, cert, r.Scheme); err != nil {
 			return err
 		}
 
-		cert.Spec = certmanagerv1.CertificateSpec{
-			SecretName: certName,
-			Duration:   &metav1.Duration{Duration: duration},
-			// Renew at 2/3 of the duration
-			RenewBefore: &metav1.Duration{Duration: duration / 3},
-			URIs:        []string{claim.Status.SpiffeID},
-			CommonName:  claim.Name,
-			IssuerRef:   r.resolveIssuerRef(claim),
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.ECDSAKeyAlgorithm,
-				Size:      256,
-			},
-		}
+		cert.Spec = *spec.DeepCopy()
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	claim.Status.SecretKeys = secretKeys(claim.Spec.Secret)
+
+	switch op {
+	case controllerutil.OperationResultCreated:
+		r.recordEvent(claim, cert, corev1.EventTypeNormal, "CertificateCreated", "Create",
+			"Created Certificate %s", certName)
+		r.recordEvent(cert, claim, corev1.EventTypeNormal, "Created", "Create",
+			"Created for IdentityClaim %s", claim.Name)
+	case controllerutil.OperationResultUpdated:
+		r.recordEvent(claim, cert, corev1.EventTypeNormal, "CertificateUpdated", "Update",
+			"Updated Certificate %s", certName)
+	}
+
+	return nil
+}
 
-	return err
+// certificateSpec returns the spec of the Certificate backing the claim.
+func (r *IdentityClaimReconciler) certificateSpec(ctx context.Context, claim *identityv1alpha1.IdentityClaim,
+	pods []corev1.Pod) (certmanagerv1.CertificateSpec, error) {
+	dnsNames, ipAddresses, err := r.resolveSANs(ctx, claim, pods)
+	if err != nil {
+		return certmanagerv1.CertificateSpec{}, err
+	}
+
+	// Calculate duration from TTL
+	duration := claim.Spec.TTL.Duration
+	if duration == 0 {
+		duration = time.Hour // default 1h
+	}
+
+	spec := certmanagerv1.CertificateSpec{
+		SecretName:  claim.Status.SecretName,
+		Duration:    &metav1.Duration{Duration: duration},
+		RenewBefore: &metav1.Duration{Duration: renewBefore(claim, duration)},
+		URIs:        []string{claim.Status.SpiffeID},
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
+		CommonName:  claim.Name,
+		IssuerRef:   r.resolveIssuerRef(claim),
+		PrivateKey:  resolvePrivateKey(claim.Spec.PrivateKey),
+	}
+	applySecretSpec(&spec, claim.Spec.Secret)
+	return spec, nil
+}
+
+// certificateFailed marks the claim Failed because its Certificate couldn't be
+// built or written, and returns err so the claim is retried.
+func (r *IdentityClaimReconciler) certificateFailed(ctx context.Context, claim *identityv1alpha1.IdentityClaim,
+	err error) (ctrl.Result, error) {
+	r.setPhase(claim, identityv1alpha1.PhaseFailed)
+	r.setCondition(claim, identityv1alpha1.ConditionCertificateIssued, metav1.ConditionFalse,
+		"CertificateFailed", err.Error())
+	recordReason("CertificateFailed")
+	if statusErr := r.Status().Update(ctx, claim); statusErr != nil {
+		logf.FromContext(ctx).Error(statusErr, "Failed to update status")
+	}
+	return ctrl.Result{}, err
+}
+
+// resolvePrivateKey maps the claim's spec.privateKey onto cert-manager, keeping
+// the historical ECDSA P-256 key when unset.
+func resolvePrivateKey(key *identityv1alpha1.PrivateKey) *certmanagerv1.CertificatePrivateKey {
+	if key == nil {
+		return &certmanagerv1.CertificatePrivateKey{
+			Algorithm: certmanagerv1.ECDSAKeyAlgorithm,
+			Size:      256,
+		}
+	}
+
+	pk := &certmanagerv1.CertificatePrivateKey{
+		Size:           key.Size,
+		Encoding:       certmanagerv1.PrivateKeyEncoding(key.Encoding),
+		RotationPolicy: certmanagerv1.PrivateKeyRotationPolicy(key.RotationPolicy),
+	}
+	switch key.Algorithm {
+	case identityv1alpha1.KeyAlgorithmRSA:
+		pk.Algorithm = certmanagerv1.RSAKeyAlgorithm
+		if pk.Size == 0 {
+			pk.Size = 2048
+		}
+	case identityv1alpha1.KeyAlgorithmEd25519:
+		pk.Algorithm = certmanagerv1.Ed25519KeyAlgorithm
+		pk.Size = 0
+	default:
+		pk.Algorithm = certmanagerv1.ECDSAKeyAlgorithm
+		if pk.Size == 0 {
+			pk.Size = 256
+		}
+	}
+	return pk
 }
 
 // resolveIssuerRef returns the issuer reference for the certificate, using
 // the claim's spec.issuerRef if set, otherwise falling back to defaults.
 func (r *IdentityClaimReconciler) resolveIssuerRef(claim *identityv1alpha1.IdentityClaim) cmmeta.ObjectReference {
+	return issuerRefFor(claim, r.DefaultIssuerName, r.DefaultIssuerKind)
+}
+
+// issuerRefFor returns the claim's spec.issuerRef with its defaults filled in,
+// or the operator's default issuer when the claim doesn't reference one.
+func issuerRefFor(claim *identityv1alpha1.IdentityClaim, defaultName, defaultKind string) cmmeta.ObjectReference {
 	if claim.Spec.IssuerRef != nil {
 		ref := cmmeta.ObjectReference{
 			Name: claim.Spec.IssuerRef.Name,
@@ -317,11 +711,11 @@ func (r *IdentityClaimReconciler) resolveIssuerRef(claim *identityv1alpha1.Ident
 		}
 		return ref
 	}
-	name := r.DefaultIssuerName
+	name := defaultName
 	if name == "" {
 		name = "selfsigned-issuer"
 	}
-	kind := r.DefaultIssuerKind
+	kind := defaultKind
 	if kind == "" {
 		kind = "ClusterIssuer"
 	}
@@ -332,8 +726,60 @@ func (r *IdentityClaimReconciler) resolveIssuerRef(claim *identityv1alpha1.Ident
 	}
 }
 
+// setPhase moves the claim to phase, emitting an event only when the phase
+// actually changes so that requeue loops don't repeat it.
+func (r *IdentityClaimReconciler) setPhase(claim *identityv1alpha1.IdentityClaim, phase identityv1alpha1.IdentityClaimPhase) {
+	previous := claim.Status.Phase
+	if previous == phase {
+		return
+	}
+	claim.Status.Phase = phase
+
+	eventType := corev1.EventTypeNormal
+	if phase == identityv1alpha1.PhaseFailed {
+		eventType = corev1.EventTypeWarning
+	}
+	if previous == "" {
+		r.recordEvent(claim, nil, eventType, string(phase), "Reconcile",
+			"Phase set to %s", phase)
+		return
+	}
+	r.recordEvent(claim, nil, eventType, string(phase), "Reconcile",
+		"Phase changed from %s to %s", previous, phase)
+}
+
+// warningReasons are the condition reasons surfaced as Warning events.
+var warningReasons = map[string]bool{
+	"InvalidTTL":           true,
+	"InvalidRenewBefore":   true,
+	"SelectorError":        true,
+	"InvalidSpiffeID":      true,
+	"NoPods":               true,
+	"OverlappingSelectors": true,
+	"SelectorConflict":     true,
+	"CertificateFailed":    true,
+	"SecretConflict":       true,
+	"PolicyViolation":      true,
+	"QuotaExceeded":        true,
+}
+
+// recordEvent emits an event if a recorder is configured.
+func (r *IdentityClaimReconciler) recordEvent(regarding, related runtime.Object, eventType, reason, action, note string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(regarding, related, eventType, reason, action, note, args...)
+}
+
 // setCondition updates or adds a condition to the claim
 func (r *IdentityClaimReconciler) setCondition(claim *identityv1alpha1.IdentityClaim, condType string, status metav1.ConditionStatus, reason, message string) {
+	// Only warn when the condition changes, so a requeue loop doesn't spam events.
+	existing := meta.FindStatusCondition(claim.Status.Conditions, condType)
+	changed := existing == nil || existing.Status != status || existing.Reason != reason
+	if changed && warningReasons[reason] {
+		r.recordEvent(claim, nil, corev1.EventTypeWarning, reason, "Reconcile", "%s", message)
+	}
+
 	meta.SetStatusCondition(&claim.Status.Conditions, metav1.Condition{
 		Type:               condType,
 		Status:             status,
@@ -344,11 +790,68 @@ func (r *IdentityClaimReconciler) setCondition(claim *identityv1alpha1.IdentityC
 	})
 }
 
+// findClaimsForPod maps a Pod to every IdentityClaim in its namespace whose
+// selector matches the pod's labels.
+func (r *IdentityClaimReconciler) findClaimsForPod(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list IdentityClaims for pod", "pod", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	podLabels := labels.Set(obj.GetLabels())
+	var requests []reconcile.Request
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
+		if err != nil {
+			// Invalid selectors are reported by Reconcile; nothing to map here.
+			continue
+		}
+		if selector.Matches(podLabels) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+			})
+		}
+	}
+	return requests
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *IdentityClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := metrics.Registry.Register(newClaimCollector(mgr.GetClient())); err != nil {
+		return err
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorder("identityclaim-controller")
+	}
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&identityv1alpha1.IdentityClaim{}).
 		Owns(&certmanagerv1.Certificate{}).
+		// Pod status churns constantly; only creation, deletion and label
+		// changes can alter which claims select a pod.
+		Watches(&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(r.findClaimsForPod),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		// A claim's selector change can create or resolve overlap with its neighbours.
+		Watches(&identityv1alpha1.IdentityClaim{},
+			handler.EnqueueRequestsFromMapFunc(r.findClaimsInNamespace),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.findClaimsForService)).
+		// Policies and quotas depend on namespace labels and annotations.
+		Watches(&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.findClaimsForNamespace),
+			builder.WithPredicates(ignoreIssuanceLedger)).
+		Watches(&identityv1alpha1.IdentityPolicy{},
+			handler.EnqueueRequestsFromMapFunc(r.findClaimsForPolicy),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Named("identityclaim").
 		Complete(r)
 }
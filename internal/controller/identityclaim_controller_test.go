@@ -18,12 +18,18 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -243,6 +249,32 @@ var _ = Describe("IdentityClaim Controller", func() {
 			}
 			Expect(found).To(BeTrue(), "should have SelectorError condition on PodsVerified")
 		})
+
+		It("should emit a single Warning event despite repeated reconciles", func() {
+			recorder := events.NewFakeRecorder(20)
+			controllerReconciler := &IdentityClaimReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			for i := 0; i < 6; i++ {
+				_, _ = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			}
+			close(recorder.Events)
+
+			var selectorWarnings, failedWarnings int
+			for event := range recorder.Events {
+				switch {
+				case strings.HasPrefix(event, "Warning SelectorError"):
+					selectorWarnings++
+				case strings.HasPrefix(event, "Warning Failed"):
+					failedWarnings++
+				}
+			}
+			Expect(selectorWarnings).To(Equal(1))
+			Expect(failedWarnings).To(Equal(1))
+		})
 	})
 
 	Context("When no pods match the selector", func() {
@@ -295,7 +327,7 @@ var _ = Describe("IdentityClaim Controller", func() {
 			// Third reconcile should hit the zero-pod guard
 			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(result.RequeueAfter).To(Equal(30*time.Second), "should requeue after 30s when no pods found")
+			Expect(result.RequeueAfter).To(BeZero(), "should rely on the Pod watch instead of polling")
 
 			// Verify the PodsVerified condition
 			claim := &identityv1alpha1.IdentityClaim{}
@@ -313,4 +345,975 @@ var _ = Describe("IdentityClaim Controller", func() {
 			Expect(podsVerifiedFound).To(BeTrue(), "PodsVerified condition should be set")
 		})
 	})
+
+	Context("When a pod matches a claim selector", func() {
+		ctx := context.Background()
+		matchingNN := types.NamespacedName{Name: "pod-watch-claim", Namespace: "default"}
+		otherNN := types.NamespacedName{Name: "pod-watch-other-claim", Namespace: "default"}
+
+		BeforeEach(func() {
+			for nn, app := range map[types.NamespacedName]string{
+				matchingNN: "pod-watch",
+				otherNN:    "something-else",
+			} {
+				Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: nn.Name, Namespace: nn.Namespace},
+					Spec: identityv1alpha1.IdentityClaimSpec{
+						Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+						TTL:      metav1.Duration{Duration: 1 * time.Hour},
+					},
+				})).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			for _, nn := range []types.NamespacedName{matchingNN, otherNN} {
+				resource := &identityv1alpha1.IdentityClaim{}
+				if err := k8sClient.Get(ctx, nn, resource); err == nil {
+					Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+				}
+			}
+		})
+
+		It("should enqueue only the claims whose selector matches", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pod-watch-pod",
+					Namespace: "default",
+					Labels:    map[string]string{"app": "pod-watch", "tier": "backend"},
+				},
+			}
+
+			requests := controllerReconciler.findClaimsForPod(ctx, pod)
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].NamespacedName).To(Equal(matchingNN))
+		})
+	})
+
+	Context("When a trust domain is configured", func() {
+		const resourceName = "trust-domain-claim"
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "trust-domain"}},
+					TTL:      metav1.Duration{Duration: 1 * time.Hour},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &identityv1alpha1.IdentityClaim{}
+			if err := k8sClient.Get(ctx, nn, resource); err == nil {
+				resource.Finalizers = nil
+				_ = k8sClient.Update(ctx, resource)
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			}
+		})
+
+		It("should issue under the configured trust domain and migrate on change", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			for i := 0; i < 3; i++ {
+				_, _ = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			}
+
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.Status.SpiffeID).To(Equal("spiffe://cluster.local/ns/default/ic/trust-domain-claim"))
+			Expect(claim.Status.TrustDomain).To(Equal("cluster.local"))
+
+			controllerReconciler.TrustDomain = "prod.example.org"
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.Status.SpiffeID).To(Equal("spiffe://prod.example.org/ns/default/ic/trust-domain-claim"))
+			Expect(claim.Status.TrustDomain).To(Equal("prod.example.org"))
+		})
+	})
+
+	Context("When spec.spiffeIDPath uses pod attributes", func() {
+		const resourceName = "spiffe-path-claim"
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+		podNames := []string{"spiffe-path-pod-a", "spiffe-path-pod-b"}
+
+		newPod := func(name, serviceAccount string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{"app": "spiffe-path"},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					Containers:         []corev1.Container{{Name: "app", Image: "busybox"}},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector:     metav1.LabelSelector{MatchLabels: map[string]string{"app": "spiffe-path"}},
+					TTL:          metav1.Duration{Duration: 1 * time.Hour},
+					SpiffeIDPath: "ns/{{.Namespace}}/sa/{{.ServiceAccount}}",
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			for _, name := range podNames {
+				pod := &corev1.Pod{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, pod); err == nil {
+					Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+				}
+			}
+			resource := &identityv1alpha1.IdentityClaim{}
+			if err := k8sClient.Get(ctx, nn, resource); err == nil {
+				resource.Finalizers = nil
+				_ = k8sClient.Update(ctx, resource)
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			}
+		})
+
+		It("should render the path from the shared service account", func() {
+			Expect(k8sClient.Create(ctx, newPod(podNames[0], "payments"))).To(Succeed())
+			Expect(k8sClient.Create(ctx, newPod(podNames[1], "payments"))).To(Succeed())
+
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			for i := 0; i < 3; i++ {
+				_, _ = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			}
+
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.Status.SpiffeID).To(Equal("spiffe://cluster.local/ns/default/sa/payments"))
+		})
+
+		It("should fail when selected pods disagree on the service account", func() {
+			Expect(k8sClient.Create(ctx, newPod(podNames[0], "payments"))).To(Succeed())
+			Expect(k8sClient.Create(ctx, newPod(podNames[1], "billing"))).To(Succeed())
+
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			for i := 0; i < 3; i++ {
+				_, _ = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			}
+
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseFailed))
+			cond := meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal("InvalidSpiffeID"))
+			Expect(cond.Message).To(ContainSubstring("billing, payments"))
+		})
+	})
+
+	Context("When spec.privateKey is an illegal combination", func() {
+		DescribeTable("should be rejected at CRD admission level",
+			func(name string, key identityv1alpha1.PrivateKey, message string) {
+				resource := &identityv1alpha1.IdentityClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec: identityv1alpha1.IdentityClaimSpec{
+						Selector:   metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+						TTL:        metav1.Duration{Duration: 1 * time.Hour},
+						PrivateKey: &key,
+					},
+				}
+				err := k8sClient.Create(context.Background(), resource)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(message))
+			},
+			Entry("ECDSA with an RSA size", "ecdsa-size-claim",
+				identityv1alpha1.PrivateKey{Algorithm: identityv1alpha1.KeyAlgorithmECDSA, Size: 2048},
+				"ECDSA key size must be 256, 384 or 521"),
+			Entry("RSA below 2048 bits", "rsa-size-claim",
+				identityv1alpha1.PrivateKey{Algorithm: identityv1alpha1.KeyAlgorithmRSA, Size: 1024},
+				"RSA key size must be between 2048 and 8192"),
+			Entry("Ed25519 with a size", "ed25519-size-claim",
+				identityv1alpha1.PrivateKey{Algorithm: identityv1alpha1.KeyAlgorithmEd25519, Size: 256},
+				"size must not be set for Ed25519 keys"),
+		)
+	})
+
+	Context("When mapping spec.privateKey to cert-manager", func() {
+		DescribeTable("should apply per-algorithm defaults",
+			func(key *identityv1alpha1.PrivateKey, expected certmanagerv1.CertificatePrivateKey) {
+				Expect(*resolvePrivateKey(key)).To(Equal(expected))
+			},
+			Entry("unset keeps ECDSA P-256", nil,
+				certmanagerv1.CertificatePrivateKey{Algorithm: certmanagerv1.ECDSAKeyAlgorithm, Size: 256}),
+			Entry("RSA defaults to 2048 bits",
+				&identityv1alpha1.PrivateKey{
+					Algorithm:      identityv1alpha1.KeyAlgorithmRSA,
+					Encoding:       identityv1alpha1.KeyEncodingPKCS8,
+					RotationPolicy: identityv1alpha1.RotationPolicyAlways,
+				},
+				certmanagerv1.CertificatePrivateKey{
+					Algorithm:      certmanagerv1.RSAKeyAlgorithm,
+					Size:           2048,
+					Encoding:       certmanagerv1.PKCS8,
+					RotationPolicy: certmanagerv1.RotationPolicyAlways,
+				}),
+			Entry("Ed25519 has no size",
+				&identityv1alpha1.PrivateKey{Algorithm: identityv1alpha1.KeyAlgorithmEd25519},
+				certmanagerv1.CertificatePrivateKey{Algorithm: certmanagerv1.Ed25519KeyAlgorithm}),
+			Entry("ECDSA P-384",
+				&identityv1alpha1.PrivateKey{Algorithm: identityv1alpha1.KeyAlgorithmECDSA, Size: 384},
+				certmanagerv1.CertificatePrivateKey{Algorithm: certmanagerv1.ECDSAKeyAlgorithm, Size: 384}),
+		)
+	})
+
+	Context("When spec.dnsNames derives names from Services", func() {
+		ctx := context.Background()
+		const serviceName = "dns-frontend"
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: "default"},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{"app": "dns"},
+					Ports:    []corev1.ServicePort{{Port: 443}},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			svc := &corev1.Service{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: "default"}, svc); err == nil {
+				Expect(k8sClient.Delete(ctx, svc)).To(Succeed())
+			}
+		})
+
+		It("should add the names and cluster IPs of Services fronting the pods", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client:        k8sClient,
+				Scheme:        k8sClient.Scheme(),
+				ClusterDomain: "example.internal",
+			}
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "dns-claim", Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "web"}},
+					DNSNames: &identityv1alpha1.DNSNames{
+						Names:        []string{"api.example.com"},
+						FromServices: true,
+					},
+				},
+			}
+			pods := []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"app": "dns", "tier": "web"},
+			}}}
+
+			svc := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: "default"}, svc)).To(Succeed())
+
+			dnsNames, ipAddresses, err := controllerReconciler.resolveSANs(ctx, claim, pods)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dnsNames).To(ConsistOf(
+				"api.example.com",
+				"dns-frontend",
+				"dns-frontend.default",
+				"dns-frontend.default.svc",
+				"dns-frontend.default.svc.example.internal",
+			))
+			Expect(ipAddresses).To(ConsistOf(svc.Spec.ClusterIPs))
+
+			By("ignoring Services that don't select any of the pods")
+			pods[0].Labels = map[string]string{"tier": "web"}
+			dnsNames, ipAddresses, err = controllerReconciler.resolveSANs(ctx, claim, pods)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dnsNames).To(ConsistOf("api.example.com"))
+			Expect(ipAddresses).To(BeEmpty())
+		})
+	})
+
+	Context("When spec.secret requests keystores without a password", func() {
+		It("should be rejected at CRD admission level", func() {
+			resource := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "keystore-no-password-claim", Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					TTL:      metav1.Duration{Duration: 1 * time.Hour},
+					Secret: &identityv1alpha1.SecretSpec{
+						Keystores: []identityv1alpha1.KeystoreType{identityv1alpha1.KeystorePKCS12},
+					},
+				},
+			}
+			err := k8sClient.Create(context.Background(), resource)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("passwordSecretRef is required when keystores are set"))
+		})
+	})
+
+	Context("When mapping spec.secret to cert-manager", func() {
+		It("should propagate metadata, keystores and output formats", func() {
+			secret := &identityv1alpha1.SecretSpec{
+				Labels:      map[string]string{"team": "payments"},
+				Annotations: map[string]string{"owner": "ops"},
+				Keystores: []identityv1alpha1.KeystoreType{
+					identityv1alpha1.KeystorePKCS12,
+					identityv1alpha1.KeystoreJKS,
+				},
+				PasswordSecretRef: &identityv1alpha1.SecretKeySelector{Name: "keystore-password", Key: "password"},
+				AdditionalOutputFormats: []identityv1alpha1.OutputFormat{
+					identityv1alpha1.OutputFormatCombinedPEM,
+					identityv1alpha1.OutputFormatDER,
+				},
+			}
+
+			spec := certmanagerv1.CertificateSpec{}
+			applySecretSpec(&spec, secret)
+
+			Expect(spec.SecretTemplate.Labels).To(HaveKeyWithValue("team", "payments"))
+			Expect(spec.SecretTemplate.Annotations).To(HaveKeyWithValue("owner", "ops"))
+			Expect(spec.Keystores.PKCS12.Create).To(BeTrue())
+			Expect(spec.Keystores.PKCS12.PasswordSecretRef.Name).To(Equal("keystore-password"))
+			Expect(spec.Keystores.JKS.Create).To(BeTrue())
+			Expect(spec.Keystores.JKS.PasswordSecretRef.Key).To(Equal("password"))
+			Expect(spec.AdditionalOutputFormats).To(ConsistOf(
+				certmanagerv1.CertificateAdditionalOutputFormat{Type: certmanagerv1.CertificateOutputFormatCombinedPEM},
+				certmanagerv1.CertificateAdditionalOutputFormat{Type: certmanagerv1.CertificateOutputFormatDER},
+			))
+
+			Expect(secretKeys(secret)).To(ConsistOf(
+				"tls.crt", "tls.key", "ca.crt",
+				"keystore.p12", "truststore.p12", "keystore.jks", "truststore.jks",
+				"tls-combined.pem", "key.der",
+			))
+			Expect(secretKeys(nil)).To(ConsistOf("tls.crt", "tls.key", "ca.crt"))
+		})
+	})
+
+	Context("When renewBefore is not shorter than ttl", func() {
+		It("should be rejected at CRD admission level", func() {
+			resource := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "long-renew-before-claim", Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector:    metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					TTL:         metav1.Duration{Duration: 1 * time.Hour},
+					RenewBefore: &metav1.Duration{Duration: 1 * time.Hour},
+				},
+			}
+			err := k8sClient.Create(context.Background(), resource)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("renewBefore must be shorter than ttl"))
+		})
+	})
+
+	Context("When renewBefore is below cert-manager's minimum", func() {
+		It("should be rejected at CRD admission level", func() {
+			resource := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "short-renew-before-claim", Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector:    metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					TTL:         metav1.Duration{Duration: 1 * time.Hour},
+					RenewBefore: &metav1.Duration{Duration: 1 * time.Minute},
+				},
+			}
+			err := k8sClient.Create(context.Background(), resource)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("renewBefore must be at least 5m"))
+		})
+	})
+
+	Context("When both renewBefore and renewBeforePercentage are set", func() {
+		It("should be rejected at CRD admission level", func() {
+			percentage := int32(50)
+			resource := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "both-renew-before-claim", Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector:              metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					TTL:                   metav1.Duration{Duration: 1 * time.Hour},
+					RenewBefore:           &metav1.Duration{Duration: 10 * time.Minute},
+					RenewBeforePercentage: &percentage,
+				},
+			}
+			err := k8sClient.Create(context.Background(), resource)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("renewBefore and renewBeforePercentage are mutually exclusive"))
+		})
+	})
+
+	Context("When computing the renewal time", func() {
+		const ttl = 1 * time.Hour
+
+		newClaim := func(uid string) *identityv1alpha1.IdentityClaim {
+			return &identityv1alpha1.IdentityClaim{ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid)}}
+		}
+
+		It("should default to a third of the ttl plus jitter", func() {
+			claim := newClaim("a")
+			Expect(renewBefore(claim, ttl)).To(BeNumerically(">=", 20*time.Minute))
+			Expect(renewBefore(claim, ttl)).To(BeNumerically("<", 20*time.Minute+4*time.Minute))
+		})
+
+		It("should honor renewBefore and renewBeforePercentage", func() {
+			claim := newClaim("a")
+			claim.Spec.RenewBefore = &metav1.Duration{Duration: 15 * time.Minute}
+			Expect(renewBefore(claim, ttl)).To(BeNumerically(">=", 15*time.Minute))
+			Expect(renewBefore(claim, ttl)).To(BeNumerically("<", 15*time.Minute+4*time.Minute+30*time.Second))
+
+			percentage := int32(25)
+			claim.Spec.RenewBefore = nil
+			claim.Spec.RenewBeforePercentage = &percentage
+			Expect(renewBefore(claim, ttl)).To(BeNumerically(">=", 15*time.Minute))
+			Expect(renewBefore(claim, ttl)).To(BeNumerically("<", 15*time.Minute+4*time.Minute+30*time.Second))
+		})
+
+		It("should be stable per claim and spread across claims", func() {
+			Expect(renewBefore(newClaim("a"), ttl)).To(Equal(renewBefore(newClaim("a"), ttl)))
+
+			seen := map[time.Duration]bool{}
+			for _, uid := range []string{"a", "b", "c", "d", "e"} {
+				seen[renewBefore(newClaim(uid), ttl)] = true
+			}
+			Expect(len(seen)).To(BeNumerically(">", 1))
+		})
+
+		It("should reject a renewBefore that is not shorter than the ttl", func() {
+			claim := newClaim("a")
+			claim.Spec.RenewBefore = &metav1.Duration{Duration: ttl}
+			Expect(validateRenewBefore(claim, ttl)).To(MatchError(ContainSubstring("renewBefore must be shorter than ttl")))
+		})
+
+		It("should reject a computed renewBefore below cert-manager's minimum", func() {
+			claim := newClaim("a")
+			percentage := int32(1)
+			claim.Spec.RenewBeforePercentage = &percentage
+			Expect(validateRenewBefore(claim, ttl)).To(MatchError(ContainSubstring("renewBefore must be at least 5m0s")))
+
+			claim.Spec.RenewBeforePercentage = nil
+			Expect(validateRenewBefore(claim, ttl)).To(Succeed())
+			Expect(validateRenewBefore(claim, 5*time.Minute)).To(MatchError(ContainSubstring("renewBefore must be at least 5m0s")))
+		})
+
+		It("should raise the default renewBefore of short TTLs to cert-manager's minimum", func() {
+			claim := newClaim("a")
+			Expect(validateRenewBefore(claim, 10*time.Minute)).To(Succeed())
+			Expect(baseRenewBefore(claim, 10*time.Minute)).To(Equal(5 * time.Minute))
+			Expect(renewBefore(claim, 10*time.Minute)).To(BeNumerically("<", 10*time.Minute))
+			Expect(baseRenewBefore(claim, 15*time.Minute)).To(Equal(5 * time.Minute))
+			Expect(baseRenewBefore(claim, 30*time.Minute)).To(Equal(10 * time.Minute))
+		})
+	})
+
+	Context("When spec.secretName is changed", func() {
+		const resourceName = "secret-name-claim"
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector:   metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					TTL:        metav1.Duration{Duration: 1 * time.Hour},
+					SecretName: "payments-tls",
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &identityv1alpha1.IdentityClaim{}
+			if err := k8sClient.Get(ctx, nn, resource); err == nil {
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			}
+		})
+
+		It("should be rejected at CRD admission level", func() {
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			claim.Spec.SecretName = "orders-tls"
+			err := k8sClient.Update(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secretName is immutable"))
+
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			claim.Spec.SecretName = ""
+			err = k8sClient.Update(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secretName is immutable"))
+		})
+	})
+
+	Context("When the Secret name is already taken", func() {
+		ctx := context.Background()
+		const foreignName = "foreign-secret"
+		const issuedName = "issued-secret"
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: foreignName, Namespace: "default"},
+			})).To(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        issuedName,
+					Namespace:   "default",
+					Annotations: map[string]string{certmanagerv1.CertificateNameKey: issuedName},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			for _, name := range []string{foreignName, issuedName} {
+				secret := &corev1.Secret{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, secret); err == nil {
+					Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+				}
+			}
+		})
+
+		It("should report a conflict only for Secrets not issued for the claim", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "conflict-claim", Namespace: "default"},
+			}
+
+			claim.Status.SecretName = foreignName
+			conflict, err := controllerReconciler.findSecretConflict(ctx, claim)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conflict).To(ContainSubstring("Secret foreign-secret already exists"))
+
+			claim.Status.SecretName = issuedName
+			conflict, err = controllerReconciler.findSecretConflict(ctx, claim)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conflict).To(BeEmpty())
+
+			claim.Status.SecretName = "unused-secret"
+			conflict, err = controllerReconciler.findSecretConflict(ctx, claim)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conflict).To(BeEmpty())
+		})
+	})
+
+	Context("When claims select the same pods", func() {
+		ctx := context.Background()
+		selectors := map[string]map[string]string{
+			"overlap-app":   {"app": "overlap"},
+			"overlap-tier":  {"tier": "overlap-backend"},
+			"overlap-other": {"app": "overlap-unrelated"},
+		}
+
+		BeforeEach(func() {
+			for name, matchLabels := range selectors {
+				Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec: identityv1alpha1.IdentityClaimSpec{
+						Selector: metav1.LabelSelector{MatchLabels: matchLabels},
+						TTL:      metav1.Duration{Duration: 1 * time.Hour},
+					},
+				})).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			for name := range selectors {
+				resource := &identityv1alpha1.IdentityClaim{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, resource); err == nil {
+					Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+				}
+			}
+		})
+
+		It("should find the claims that share a selected pod", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "overlap-app", Namespace: "default"}, claim)).To(Succeed())
+
+			pods := []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{
+				Name:      "overlap-pod",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "overlap", "tier": "overlap-backend"},
+			}}}
+			overlapping, err := controllerReconciler.findOverlappingClaims(ctx, claim, pods)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overlapping).To(HaveLen(1))
+			Expect(overlapping[0].Name).To(Equal("overlap-tier"))
+
+			pods[0].Labels = map[string]string{"app": "overlap"}
+			overlapping, err = controllerReconciler.findOverlappingClaims(ctx, claim, pods)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overlapping).To(BeEmpty())
+		})
+
+		It("should enqueue the other claims in the namespace", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "overlap-app", Namespace: "default"},
+			}
+
+			requests := controllerReconciler.findClaimsInNamespace(ctx, claim)
+			Expect(requests).To(ContainElements(
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "overlap-tier", Namespace: "default"}},
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "overlap-other", Namespace: "default"}},
+			))
+			Expect(requests).NotTo(ContainElement(
+				reconcile.Request{NamespacedName: types.NamespacedName{Name: "overlap-app", Namespace: "default"}}))
+		})
+
+		It("should order claims by age and then by name", func() {
+			now := metav1.Now()
+			older := &identityv1alpha1.IdentityClaim{ObjectMeta: metav1.ObjectMeta{
+				Name: "b", CreationTimestamp: metav1.NewTime(now.Add(-time.Minute)),
+			}}
+			younger := &identityv1alpha1.IdentityClaim{ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: now}}
+			twin := &identityv1alpha1.IdentityClaim{ObjectMeta: metav1.ObjectMeta{Name: "c", CreationTimestamp: now}}
+
+			Expect(isOlderClaim(older, younger)).To(BeTrue())
+			Expect(isOlderClaim(younger, older)).To(BeFalse())
+			Expect(isOlderClaim(younger, twin)).To(BeTrue())
+			Expect(isOlderClaim(twin, younger)).To(BeFalse())
+		})
+	})
+
+	Context("When an IdentityPolicy applies to the namespace", func() {
+		ctx := context.Background()
+		const policyName = "controller-default-policy"
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+				Spec: identityv1alpha1.IdentityPolicySpec{
+					NamespaceSelector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": "default"},
+					},
+					AllowedIssuers:       []identityv1alpha1.IssuerReference{{Name: "team-issuer"}},
+					AllowedSpiffeIDPaths: []string{"ns/default/ic/*"},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+			})).To(Succeed())
+		})
+
+		It("should check the resolved issuer and the rendered SPIFFE ID", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client:            k8sClient,
+				Scheme:            k8sClient.Scheme(),
+				DefaultIssuerName: "team-issuer",
+			}
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy-claim", Namespace: "default"},
+			}
+
+			violation, err := controllerReconciler.checkPolicies(ctx, claim, "spiffe://cluster.local/ns/default/ic/policy-claim")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violation).To(BeNil())
+
+			violation, err = controllerReconciler.checkPolicies(ctx, claim, "spiffe://cluster.local/workload/policy-claim")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violation).NotTo(BeNil())
+			Expect(violation.Error()).To(ContainSubstring("denied by IdentityPolicy controller-default-policy: spec.spiffeIDPath"))
+
+			controllerReconciler.DefaultIssuerName = "production-root"
+			violation, err = controllerReconciler.checkPolicies(ctx, claim, "spiffe://cluster.local/ns/default/ic/policy-claim")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violation).NotTo(BeNil())
+			Expect(violation.Field.String()).To(Equal("spec.issuerRef"))
+		})
+
+		It("should enqueue every claim when a policy changes", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			claims := &identityv1alpha1.IdentityClaimList{}
+			Expect(k8sClient.List(ctx, claims)).To(Succeed())
+
+			requests := controllerReconciler.findClaimsForPolicy(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+			})
+			Expect(requests).To(HaveLen(len(claims.Items)))
+		})
+	})
+
+	Context("When a namespace has a quota", func() {
+		ctx := context.Background()
+		// envtest can't delete namespaces, so this one is only used here
+		const namespace = "quota-test"
+		const policyName = "quota-test-policy"
+		limit := func(n int32) *int32 { return &n }
+
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: namespace}, ns); errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			}
+			ns.Annotations = nil
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+				Spec: identityv1alpha1.IdentityPolicySpec{
+					NamespaceSelector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace},
+					},
+					Quota: &identityv1alpha1.IdentityQuota{MaxClaims: limit(1)},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+			})).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &identityv1alpha1.IdentityClaim{}, client.InNamespace(namespace))).To(Succeed())
+		})
+
+		It("should let policies override the flags and annotations override policies", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client:                k8sClient,
+				Scheme:                k8sClient.Scheme(),
+				MaxClaimsPerNamespace: 50,
+				MaxIssuancesPerHour:   100,
+			}
+
+			quota, err := controllerReconciler.resolveQuota(ctx, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quota).To(Equal(namespaceQuota{maxClaims: 1, maxIssuancesPerHour: 100}))
+
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespace}, ns)).To(Succeed())
+			ns.Annotations = map[string]string{
+				identityv1alpha1.MaxClaimsAnnotation:           "0",
+				identityv1alpha1.MaxIssuancesPerHourAnnotation: "not-a-number",
+			}
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			quota, err = controllerReconciler.resolveQuota(ctx, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quota).To(Equal(namespaceQuota{maxClaims: 0, maxIssuancesPerHour: 100}))
+		})
+
+		It("should hold back claims beyond the claim limit, youngest first", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			newClaim := func(name string) *identityv1alpha1.IdentityClaim {
+				claim := &identityv1alpha1.IdentityClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Spec: identityv1alpha1.IdentityClaimSpec{
+						Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+						TTL:      metav1.Duration{Duration: 1 * time.Hour},
+					},
+				}
+				Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+				return claim
+			}
+			// Creation timestamps have second precision, so the name breaks the tie
+			first := newClaim("quota-a")
+			second := newClaim("quota-b")
+
+			violation, err := controllerReconciler.checkQuota(ctx, first, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violation).To(BeNil())
+
+			violation, err = controllerReconciler.checkQuota(ctx, second, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(violation).NotTo(BeNil())
+			Expect(violation.quota).To(Equal(quotaClaims))
+			Expect(violation.reason).To(Equal("ClaimLimit"))
+		})
+	})
+
+	Context("When a claim with a deletionPolicy is deleted", func() {
+		const resourceName = "deletion-claim"
+		const secretName = "deletion-claim-identity"
+		const podName = "deletion-pod"
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		// newDeletedClaim creates a claim as the reconciler leaves it once
+		// issued, with an identity Secret owned by its Certificate, and deletes it.
+		newDeletedClaim := func(policy identityv1alpha1.DeletionPolicy, grace *metav1.Duration) {
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       resourceName,
+					Namespace:  "default",
+					Finalizers: []string{finalizerName},
+				},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector:            metav1.LabelSelector{MatchLabels: map[string]string{"app": "deletion"}},
+					TTL:                 metav1.Duration{Duration: 1 * time.Hour},
+					DeletionPolicy:      policy,
+					DeletionGracePeriod: grace,
+				},
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+			claim.Status.Phase = identityv1alpha1.PhaseReady
+			claim.Status.SecretName = secretName
+			Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secretName,
+					Namespace:   "default",
+					Annotations: map[string]string{certmanagerv1.CertificateNameKey: secretName},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "cert-manager.io/v1",
+						Kind:       "Certificate",
+						Name:       secretName,
+						UID:        "00000000-0000-0000-0000-000000000001",
+					}},
+				},
+			})).To(Succeed())
+			Expect(k8sClient.Delete(ctx, claim)).To(Succeed())
+		}
+
+		AfterEach(func() {
+			pod := &corev1.Pod{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: podName, Namespace: "default"}, pod); err == nil {
+				Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+			}
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: "default"}, secret); err == nil {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			}
+			resource := &identityv1alpha1.IdentityClaim{}
+			if err := k8sClient.Get(ctx, nn, resource); err == nil {
+				resource.Finalizers = nil
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			}
+		})
+
+		It("should keep the Secret while selected pods run within the grace period", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(k8sClient.Create(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: "default",
+					Labels:    map[string]string{"app": "deletion"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			})).To(Succeed())
+			newDeletedClaim(identityv1alpha1.DeletionPolicyDelete, &metav1.Duration{Duration: 1 * time.Hour})
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.Status.DeletionDeadline).NotTo(BeNil())
+			Expect(claim.Status.DeletionDeadline.Time).To(BeTemporally("~", claim.DeletionTimestamp.Add(time.Hour), time.Second))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: "default"}, &corev1.Secret{})).To(Succeed())
+
+			By("deleting the last selected pod")
+			Expect(k8sClient.Delete(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: "default"},
+			})).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			// What happens to the Certificate and Secret is covered by the
+			// IdentityClaim deletion specs, which hold cert-manager objects.
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, nn, &identityv1alpha1.IdentityClaim{}))).To(BeTrue())
+		})
+	})
+
+	Context("When a claim is suspended", func() {
+		const resourceName = "suspended-claim"
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       resourceName,
+					Namespace:  "default",
+					Finalizers: []string{finalizerName},
+				},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "suspended"}},
+					TTL:      metav1.Duration{Duration: 1 * time.Hour},
+					Suspend:  true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+			claim.Status.Phase = identityv1alpha1.PhaseReady
+			claim.Status.SecretName = resourceName + "-identity"
+			Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &identityv1alpha1.IdentityClaim{}
+			if err := k8sClient.Get(ctx, nn, resource); err == nil {
+				resource.Finalizers = nil
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			}
+		})
+
+		It("should leave the claim as it is until resumed", func() {
+			controllerReconciler := &IdentityClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseReady))
+			cond := meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionSuspended)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: claim.Status.SecretName, Namespace: "default"},
+				&certmanagerv1.Certificate{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			By("reconciling the suspended claim again")
+			resourceVersion := claim.ResourceVersion
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(claim.ResourceVersion).To(Equal(resourceVersion))
+
+			By("unsetting spec.suspend")
+			claim.Spec.Suspend = false
+			Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			Expect(meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionSuspended)).To(BeNil())
+		})
+	})
 })
@@ -0,0 +1,185 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+const (
+	// issuancesAnnotation is the namespace's issuance ledger: when each
+	// CertificateRequest counted toward its issuance rate was created, by UID.
+	// It outlives the requests, so deleting a claim doesn't reset the rate.
+	issuancesAnnotation = "identity.cluster.local/issuances"
+	// pendingIssuancePrefix prefixes the ledger entries of issuances a claim
+	// decided on, by the claim's UID, until their CertificateRequest replaces
+	// them. They count right away, when cert-manager hasn't requested yet.
+	pendingIssuancePrefix = "claim/"
+)
+
+// IssuanceReconciler records every CertificateRequest made for an
+// IdentityClaim in its namespace's issuance ledger, which the
+// IdentityClaimReconciler enforces maxIssuancesPerHour against.
+type IssuanceReconciler struct {
+	client.Client
+}
+
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificaterequests,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;patch
+
+// Reconcile adds a CertificateRequest to the issuance ledger of its namespace
+// and drops the entries that left the window.
+func (r *IssuanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	request := &metav1.PartialObjectMetadata{}
+	request.SetGroupVersionKind(certmanagerv1.SchemeGroupVersion.WithKind("CertificateRequest"))
+	if err := r.Get(ctx, req.NamespacedName, request); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	now := time.Now()
+	if now.Sub(request.CreationTimestamp.Time) >= issuanceWindow {
+		return ctrl.Result{}, nil
+	}
+	counted, claimUID, err := r.countsTowardQuota(ctx, request)
+	if err != nil || !counted {
+		return ctrl.Result{}, err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: request.Namespace}, ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	ledger := issuances(ctx, ns, now)
+	if _, ok := ledger[string(request.UID)]; ok {
+		return ctrl.Result{}, nil
+	}
+	ledger[string(request.UID)] = request.CreationTimestamp
+	if claimUID != "" {
+		delete(ledger, pendingIssuancePrefix+string(claimUID))
+	}
+	return ctrl.Result{}, recordIssuances(ctx, r.Client, ns, ledger)
+}
+
+// recordIssuances writes ledger as the issuance ledger of ns. Concurrent
+// updates of the ledger conflict rather than drop entries.
+func recordIssuances(ctx context.Context, c client.Client, ns *corev1.Namespace, ledger map[string]metav1.Time) error {
+	value, err := json.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFromWithOptions(ns.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[issuancesAnnotation] = string(value)
+	if err := c.Patch(ctx, ns, patch); err != nil {
+		return fmt.Errorf("failed to record issuance in namespace %s: %w", ns.Name, err)
+	}
+	return nil
+}
+
+// countsTowardQuota reports whether a CertificateRequest issues an
+// IdentityClaim's certificate: it belongs to a CSI volume, or to a Certificate
+// an IdentityClaim controls, whose UID is returned. Requests whose Certificate
+// is already gone count too, since a claim deleted right after its issuance
+// leaves them behind.
+func (r *IssuanceReconciler) countsTowardQuota(ctx context.Context, request *metav1.PartialObjectMetadata) (bool, types.UID, error) {
+	if _, ok := request.Labels[identityv1alpha1.ClaimLabel]; ok {
+		return true, "", nil
+	}
+	owner := metav1.GetControllerOf(request)
+	if owner == nil || owner.Kind != "Certificate" {
+		return false, "", nil
+	}
+	cert := &certmanagerv1.Certificate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: request.Namespace, Name: owner.Name}, cert); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, "", nil
+		}
+		return false, "", err
+	}
+	if cert.UID != owner.UID {
+		return true, "", nil
+	}
+	claimOwner := metav1.GetControllerOf(cert)
+	if claimOwner == nil || claimOwner.Kind != "IdentityClaim" {
+		return false, "", nil
+	}
+	return true, claimOwner.UID, nil
+}
+
+// issuances returns the issuance ledger of ns without the entries older than
+// the issuance window. A ledger that can't be parsed is started over.
+func issuances(ctx context.Context, ns *corev1.Namespace, now time.Time) map[string]metav1.Time {
+	ledger := make(map[string]metav1.Time)
+	value, ok := ns.Annotations[issuancesAnnotation]
+	if !ok {
+		return ledger
+	}
+	if err := json.Unmarshal([]byte(value), &ledger); err != nil {
+		logf.FromContext(ctx).Info("Ignoring invalid issuance ledger", "namespace", ns.Name, "error", err.Error())
+		return make(map[string]metav1.Time)
+	}
+	maps.DeleteFunc(ledger, func(_ string, at metav1.Time) bool {
+		return now.Sub(at.Time) >= issuanceWindow
+	})
+	return ledger
+}
+
+// ignoreIssuanceLedger filters out Namespace updates that only change the
+// issuance ledger, which doesn't change any claim's policies or limits.
+var ignoreIssuanceLedger = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldAnnotations := maps.Clone(e.ObjectOld.GetAnnotations())
+		newAnnotations := maps.Clone(e.ObjectNew.GetAnnotations())
+		delete(oldAnnotations, issuancesAnnotation)
+		delete(newAnnotations, issuancesAnnotation)
+		return !maps.Equal(oldAnnotations, newAnnotations) ||
+			!maps.Equal(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+	},
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IssuanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Only the metadata of CertificateRequests is needed, not their
+	// certificates and CSRs.
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&certmanagerv1.CertificateRequest{}, builder.OnlyMetadata,
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(event.UpdateEvent) bool { return false },
+				DeleteFunc: func(event.DeleteEvent) bool { return false },
+			})).
+		Named("issuance").
+		Complete(r)
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// The controller suite's envtest doesn't install cert-manager's CRDs, so the
+// issuance ledger is kept against a fake client.
+var _ = Describe("Issuance ledger", func() {
+	ctx := context.Background()
+
+	// ledger returns the issuance ledger of the default namespace.
+	ledger := func(c client.Client) map[string]metav1.Time {
+		ns := &corev1.Namespace{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "default"}, ns)).To(Succeed())
+		return issuances(ctx, ns, time.Now())
+	}
+
+	// newRequest returns a CertificateRequest created now and controlled by
+	// the Certificate certName.
+	newRequest := func(name, certName string, certUID types.UID) *certmanagerv1.CertificateRequest {
+		controller := true
+		return &certmanagerv1.CertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				UID:               types.UID(name + "-uid"),
+				CreationTimestamp: metav1.Now(),
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: certmanagerv1.SchemeGroupVersion.String(),
+					Kind:       "Certificate",
+					Name:       certName,
+					UID:        certUID,
+					Controller: &controller,
+				}},
+			},
+		}
+	}
+
+	It("should keep counting the requests of deleted claims", func() {
+		claim := &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "ledger-claim", Namespace: "default", UID: "ledger-claim-uid"},
+		}
+		claimCert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "ledger-claim-identity", Namespace: "default", UID: "claim-cert-uid"},
+		}
+		Expect(controllerutil.SetControllerReference(claim, claimCert, scheme.Scheme)).To(Succeed())
+		otherCert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress-tls", Namespace: "default", UID: "other-cert-uid"},
+		}
+		csiRequest := &certmanagerv1.CertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "csi",
+				Namespace:         "default",
+				UID:               "csi-uid",
+				CreationTimestamp: metav1.Now(),
+				Labels:            map[string]string{identityv1alpha1.ClaimLabel: "web"},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				claimCert, otherCert, csiRequest,
+				newRequest("claim", claimCert.Name, claimCert.UID),
+				newRequest("deleted", "deleted-claim-identity", "deleted-cert-uid"),
+				newRequest("other", otherCert.Name, otherCert.UID)).
+			Build()
+		r := &IssuanceReconciler{Client: c}
+
+		for _, name := range []string{"claim", "deleted", "other", "csi", "claim"} {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(ledger(c)).To(SatisfyAll(
+			HaveLen(3),
+			HaveKey("claim-uid"),
+			HaveKey("deleted-uid"),
+			HaveKey("csi-uid"),
+		))
+
+		By("deleting the requests")
+		Expect(c.DeleteAllOf(ctx, &certmanagerv1.CertificateRequest{}, client.InNamespace("default"))).To(Succeed())
+		Expect(ledger(c)).To(HaveLen(3))
+	})
+
+	It("should drop issuances that left the window", func() {
+		value, err := json.Marshal(map[string]metav1.Time{
+			"old":    metav1.NewTime(time.Now().Add(-2 * issuanceWindow)),
+			"recent": metav1.NewTime(time.Now().Add(-time.Minute)),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Annotations: map[string]string{issuancesAnnotation: string(value)},
+		}}
+		Expect(issuances(ctx, ns, time.Now())).To(SatisfyAll(HaveLen(1), HaveKey("recent")))
+
+		ns.Annotations[issuancesAnnotation] = "not-json"
+		Expect(issuances(ctx, ns, time.Now())).To(BeEmpty())
+	})
+
+	It("should count issuances before cert-manager requests them", func() {
+		const limit = 2
+		objects := []client.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "default",
+				Annotations: map[string]string{identityv1alpha1.MaxIssuancesPerHourAnnotation: "2"},
+			}},
+		}
+		var claims []*identityv1alpha1.IdentityClaim
+		for _, name := range []string{"burst-a", "burst-b", "burst-c"} {
+			claim := &identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       name,
+					Namespace:  "default",
+					UID:        types.UID(name + "-uid"),
+					Finalizers: []string{finalizerName},
+				},
+				Spec: identityv1alpha1.IdentityClaimSpec{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+					TTL:      metav1.Duration{Duration: 1 * time.Hour},
+				},
+				Status: identityv1alpha1.IdentityClaimStatus{
+					Phase:      identityv1alpha1.PhasePending,
+					SecretName: name + "-identity",
+				},
+			}
+			claims = append(claims, claim)
+			objects = append(objects, claim, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:      name + "-pod",
+				Namespace: "default",
+				Labels:    map[string]string{"app": name},
+			}})
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(&identityv1alpha1.IdentityClaim{}).
+			Build()
+		r := &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+
+		for _, claim := range claims {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		certs := &certmanagerv1.CertificateList{}
+		Expect(c.List(ctx, certs, client.InNamespace("default"))).To(Succeed())
+		Expect(certs.Items).To(HaveLen(limit))
+		Expect(ledger(c)).To(SatisfyAll(HaveLen(limit), HaveKey("claim/burst-a-uid"), HaveKey("claim/burst-b-uid")))
+		held := &identityv1alpha1.IdentityClaim{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(claims[limit]), held)).To(Succeed())
+		quota := meta.FindStatusCondition(held.Status.Conditions, identityv1alpha1.ConditionQuotaExceeded)
+		Expect(quota).NotTo(BeNil())
+		Expect(quota.Reason).To(Equal("IssuanceRateLimit"))
+
+		By("cert-manager requesting the first certificate")
+		cert := &certmanagerv1.Certificate{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "burst-a-identity", Namespace: "default"}, cert)).To(Succeed())
+		Expect(c.Create(ctx, newRequest("burst-a", cert.Name, cert.UID))).To(Succeed())
+		_, err := (&IssuanceReconciler{Client: c}).Reconcile(ctx,
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "burst-a", Namespace: "default"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ledger(c)).To(SatisfyAll(HaveLen(limit), HaveKey("burst-a-uid"), HaveKey("claim/burst-b-uid")))
+	})
+
+	It("should hold back a rotation once the namespace used its issuances", func() {
+		const secretName = "ledger-claim-identity"
+		nn := types.NamespacedName{Name: "ledger-claim", Namespace: "default"}
+		claim := &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        nn.Name,
+				Namespace:   nn.Namespace,
+				UID:         "00000000-0000-0000-0000-000000000016",
+				Finalizers:  []string{finalizerName},
+				Annotations: map[string]string{identityv1alpha1.RotateRequestedAtAnnotation: "2026-01-02T15:04:05Z"},
+			},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "ledger"}},
+				TTL:      metav1.Duration{Duration: 1 * time.Hour},
+			},
+			Status: identityv1alpha1.IdentityClaimStatus{
+				Phase:      identityv1alpha1.PhaseReady,
+				SecretName: secretName,
+			},
+		}
+		cert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+			Status: certmanagerv1.CertificateStatus{
+				Conditions: []certmanagerv1.CertificateCondition{{
+					Type:   certmanagerv1.CertificateConditionReady,
+					Status: cmmeta.ConditionTrue,
+				}},
+			},
+		}
+		Expect(controllerutil.SetControllerReference(claim, cert, scheme.Scheme)).To(Succeed())
+		value, err := json.Marshal(map[string]metav1.Time{"earlier": metav1.NewTime(time.Now().Add(-time.Minute))})
+		Expect(err).NotTo(HaveOccurred())
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+					Name: "default",
+					Annotations: map[string]string{
+						identityv1alpha1.MaxIssuancesPerHourAnnotation: "1",
+						issuancesAnnotation:                            string(value),
+					},
+				}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name:      "ledger-pod",
+					Namespace: "default",
+					Labels:    map[string]string{"app": "ledger"},
+				}},
+				claim, cert).
+			WithStatusSubresource(claim, cert).
+			Build()
+		r := &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+
+		result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", issuanceWindow-time.Minute, time.Minute))
+
+		Expect(c.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.LastRotationTime).To(BeNil())
+		quota := meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionQuotaExceeded)
+		Expect(quota).NotTo(BeNil())
+		Expect(quota.Reason).To(Equal("IssuanceRateLimit"))
+	})
+})
@@ -0,0 +1,154 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+const metricsNamespace = "identityclaim"
+
+var (
+	// issuanceDuration tracks the time from claim creation until the claim reaches PhaseReady.
+	issuanceDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "issuance_duration_seconds",
+		Help:      "Time from IdentityClaim creation until it reaches the Ready phase.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	})
+
+	// conditionReasons counts how often the reconciler reported a failure reason.
+	conditionReasons = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "condition_reason_total",
+		Help:      "Number of times a failure reason was set on an IdentityClaim condition.",
+	}, []string{"reason"})
+
+	// quotaExceeded counts how often a claim was held back by its namespace's quota.
+	quotaExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "quota_exceeded_total",
+		Help:      "Number of times an IdentityClaim was not issued because its namespace exceeded a quota.",
+	}, []string{"namespace", "quota"})
+)
+
+var (
+	claimsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "claims"),
+		"Number of IdentityClaims per namespace and phase.",
+		[]string{"namespace", "phase"}, nil)
+
+	suspendedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "suspended_claims"),
+		"Number of IdentityClaims per namespace with spec.suspend set.",
+		[]string{"namespace"}, nil)
+
+	expirySecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "certificate_expiry_seconds"),
+		"Seconds until the certificate of an IdentityClaim expires.",
+		[]string{"namespace", "name"}, nil)
+)
+
+// allPhases lists the phases reported for every namespace, so that alerts see
+// an explicit zero rather than a missing series.
+var allPhases = []identityv1alpha1.IdentityClaimPhase{
+	identityv1alpha1.PhasePending,
+	identityv1alpha1.PhaseIssuing,
+	identityv1alpha1.PhaseReady,
+	identityv1alpha1.PhaseFailed,
+}
+
+func init() {
+	metrics.Registry.MustRegister(issuanceDuration, conditionReasons, quotaExceeded)
+}
+
+// recordReason increments the counter for a failure condition reason.
+func recordReason(reason string) {
+	conditionReasons.WithLabelValues(reason).Inc()
+}
+
+// recordIssuance observes the issuance latency of a claim that just became Ready.
+func recordIssuance(claim *identityv1alpha1.IdentityClaim) {
+	issuanceDuration.Observe(time.Since(claim.CreationTimestamp.Time).Seconds())
+}
+
+// claimCollector computes per-phase and expiry gauges from the current set of
+// IdentityClaims at scrape time, so the values never drift from the cluster state.
+type claimCollector struct {
+	reader client.Reader
+	now    func() time.Time
+}
+
+// newClaimCollector returns a collector reading IdentityClaims through reader.
+func newClaimCollector(reader client.Reader) *claimCollector {
+	return &claimCollector{reader: reader, now: time.Now}
+}
+
+// Describe implements prometheus.Collector
+func (c *claimCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- claimsDesc
+	ch <- suspendedDesc
+	ch <- expirySecondsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *claimCollector) Collect(ch chan<- prometheus.Metric) {
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := c.reader.List(context.Background(), claims); err != nil {
+		logf.Log.WithName("metrics").Error(err, "failed to list IdentityClaims")
+		return
+	}
+
+	counts := map[string]map[identityv1alpha1.IdentityClaimPhase]int{}
+	suspended := map[string]int{}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if counts[claim.Namespace] == nil {
+			counts[claim.Namespace] = map[identityv1alpha1.IdentityClaimPhase]int{}
+		}
+		phase := claim.Status.Phase
+		if phase == "" {
+			phase = identityv1alpha1.PhasePending
+		}
+		counts[claim.Namespace][phase]++
+		if claim.Spec.Suspend {
+			suspended[claim.Namespace]++
+		}
+
+		if claim.Status.ExpiresAt != nil {
+			ch <- prometheus.MustNewConstMetric(expirySecondsDesc, prometheus.GaugeValue,
+				claim.Status.ExpiresAt.Sub(c.now()).Seconds(), claim.Namespace, claim.Name)
+		}
+	}
+
+	for namespace, phases := range counts {
+		for _, phase := range allPhases {
+			ch <- prometheus.MustNewConstMetric(claimsDesc, prometheus.GaugeValue,
+				float64(phases[phase]), namespace, string(phase))
+		}
+		ch <- prometheus.MustNewConstMetric(suspendedDesc, prometheus.GaugeValue,
+			float64(suspended[namespace]), namespace)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+var _ = Describe("IdentityClaim metrics", func() {
+	It("should report claims per phase and seconds until expiry", func() {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		expires := metav1.NewTime(now.Add(2 * time.Hour))
+
+		reader := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).WithObjects(
+			&identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "team-a"},
+				Status: identityv1alpha1.IdentityClaimStatus{
+					Phase:     identityv1alpha1.PhaseReady,
+					ExpiresAt: &expires,
+				},
+			},
+			&identityv1alpha1.IdentityClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "team-a"},
+				Spec:       identityv1alpha1.IdentityClaimSpec{Suspend: true},
+				Status:     identityv1alpha1.IdentityClaimStatus{Phase: identityv1alpha1.PhaseFailed},
+			},
+		).Build()
+
+		collector := newClaimCollector(reader)
+		collector.now = func() time.Time { return now }
+
+		registry := prometheus.NewRegistry()
+		Expect(registry.Register(collector)).To(Succeed())
+		families, err := registry.Gather()
+		Expect(err).NotTo(HaveOccurred())
+
+		values := map[string]float64{}
+		for _, family := range families {
+			for _, m := range family.GetMetric() {
+				values[family.GetName()+labelString(m)] = m.GetGauge().GetValue()
+			}
+		}
+
+		Expect(values).To(HaveKeyWithValue("identityclaim_claims{namespace=team-a,phase=Ready}", 1.0))
+		Expect(values).To(HaveKeyWithValue("identityclaim_claims{namespace=team-a,phase=Failed}", 1.0))
+		Expect(values).To(HaveKeyWithValue("identityclaim_claims{namespace=team-a,phase=Pending}", 0.0))
+		Expect(values).To(HaveKeyWithValue("identityclaim_suspended_claims{namespace=team-a}", 1.0))
+		Expect(values).To(HaveKeyWithValue(
+			"identityclaim_certificate_expiry_seconds{name=ready,namespace=team-a}", 7200.0))
+	})
+})
+
+// labelString renders the labels of m as {k=v,...} in the order Prometheus sorts them.
+func labelString(m *dto.Metric) string {
+	s := "{"
+	for i, l := range m.GetLabel() {
+		if i > 0 {
+			s += ","
+		}
+		s += l.GetName() + "=" + l.GetValue()
+	}
+	return s + "}"
+}
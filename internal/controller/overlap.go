@@ -0,0 +1,95 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// findOverlappingClaims returns the other claims in the namespace that select at
+// least one of pods, sorted by name. Overlap is computed on the actual pods rather
+// than the selectors, so disjoint-looking selectors that happen to match the same
+// pod are caught and overlapping selectors without shared pods are not.
+func (r *IdentityClaimReconciler) findOverlappingClaims(ctx context.Context, claim *identityv1alpha1.IdentityClaim, pods []corev1.Pod) ([]identityv1alpha1.IdentityClaim, error) {
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(claim.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list IdentityClaims: %w", err)
+	}
+
+	var overlapping []identityv1alpha1.IdentityClaim
+	for _, other := range claims.Items {
+		if other.UID == claim.UID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&other.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(pods, func(pod corev1.Pod) bool { return selector.Matches(labels.Set(pod.Labels)) }) {
+			overlapping = append(overlapping, other)
+		}
+	}
+	slices.SortFunc(overlapping, func(a, b identityv1alpha1.IdentityClaim) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return overlapping, nil
+}
+
+// isOlderClaim reports whether a was created before b, breaking ties by name so
+// that exactly one of two overlapping claims is considered the older.
+func isOlderClaim(a, b *identityv1alpha1.IdentityClaim) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// findClaimsInNamespace maps an IdentityClaim to the other claims in its
+// namespace, so that a selector change re-evaluates their overlap.
+func (r *IdentityClaimReconciler) findClaimsInNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list IdentityClaims", "claim", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, claim := range claims.Items {
+		if claim.Name == obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/url"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+	"github.com/osagberg/identity-claim-operator/internal/policy"
+)
+
+// checkPolicies evaluates the claim against the IdentityPolicies of its namespace
+// using the values it is actually issued with: the resolved issuer and the
+// rendered SPIFFE ID, which the admission webhook can't know in advance.
+func (r *IdentityClaimReconciler) checkPolicies(ctx context.Context, claim *identityv1alpha1.IdentityClaim, spiffeID string) (*policy.Violation, error) {
+	req := policy.NewRequest(claim)
+	issuer := r.resolveIssuerRef(claim)
+	req.Issuer = &identityv1alpha1.IssuerReference{Name: issuer.Name, Kind: issuer.Kind, Group: issuer.Group}
+	req.SpiffeIDPath = ""
+	if u, err := url.Parse(spiffeID); err == nil {
+		req.SpiffeIDPath = u.Path
+	}
+	return policy.Evaluate(ctx, r.Client, claim.Namespace, req)
+}
+
+// deleteDeniedCertificate deletes the Certificate of a claim that violates a
+// policy, so cert-manager doesn't keep renewing an identity issued before the
+// policy existed. The Secret keeps the last certificate until it expires; the
+// Certificate is recreated once the claim complies again.
+func (r *IdentityClaimReconciler) deleteDeniedCertificate(ctx context.Context, claim *identityv1alpha1.IdentityClaim, violation *policy.Violation) error {
+	log := logf.FromContext(ctx)
+
+	if claim.Status.SecretName == "" {
+		return nil
+	}
+	cert := &certmanagerv1.Certificate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}, cert); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(cert, claim) {
+		return nil
+	}
+	log.Info("Deleting Certificate denied by policy", "name", cert.Name, "policy", violation.Policy)
+	if err := r.Delete(ctx, cert); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	r.recordEvent(cert, claim, corev1.EventTypeWarning, "Deleted", "Reconcile",
+		"Certificate deleted because IdentityClaim %s is denied by IdentityPolicy %s", claim.Name, violation.Policy)
+	return nil
+}
+
+// findClaimsForPolicy maps an IdentityPolicy to every IdentityClaim, so that
+// claims are re-evaluated when a policy is created, changed or deleted.
+func (r *IdentityClaimReconciler) findClaimsForPolicy(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims); err != nil {
+		log.Error(err, "failed to list IdentityClaims for policy", "policy", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(claims.Items))
+	for _, claim := range claims.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+		})
+	}
+	return requests
+}
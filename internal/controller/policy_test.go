@@ -0,0 +1,106 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// The controller suite's envtest doesn't install cert-manager's CRDs, so
+// policies are enforced against a fake client holding an issued claim.
+var _ = Describe("IdentityClaim policy enforcement", func() {
+	const (
+		resourceName = "policy-claim"
+		secretName   = "policy-claim-identity"
+	)
+	ctx := context.Background()
+	nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	It("should delete the Certificate of a Ready claim once a policy denies it", func() {
+		claim := &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       resourceName,
+				Namespace:  "default",
+				UID:        "00000000-0000-0000-0000-000000000015",
+				Finalizers: []string{finalizerName},
+			},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "policy"}},
+				TTL:      metav1.Duration{Duration: 1 * time.Hour},
+			},
+			Status: identityv1alpha1.IdentityClaimStatus{
+				Phase:      identityv1alpha1.PhaseReady,
+				SecretName: secretName,
+			},
+		}
+		cert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+			Status: certmanagerv1.CertificateStatus{
+				Conditions: []certmanagerv1.CertificateCondition{{
+					Type:   certmanagerv1.CertificateConditionReady,
+					Status: cmmeta.ConditionTrue,
+				}},
+			},
+		}
+		Expect(controllerutil.SetControllerReference(claim, cert, scheme.Scheme)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name:      "policy-pod",
+					Namespace: "default",
+					Labels:    map[string]string{"app": "policy"},
+				}},
+				&identityv1alpha1.IdentityPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "vault-only"},
+					Spec: identityv1alpha1.IdentityPolicySpec{
+						AllowedIssuers: []identityv1alpha1.IssuerReference{{Name: "vault-issuer"}},
+					},
+				},
+				claim, cert).
+			WithStatusSubresource(claim, cert).
+			Build()
+		r := &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: "default"}, &certmanagerv1.Certificate{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(c.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseFailed))
+		Expect(meta.IsStatusConditionTrue(claim.Status.Conditions, identityv1alpha1.ConditionPolicyViolation)).To(BeTrue())
+	})
+})
@@ -0,0 +1,211 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+	"github.com/osagberg/identity-claim-operator/internal/policy"
+)
+
+// issuanceWindow is the period issuances are counted over for the rate limit.
+const issuanceWindow = time.Hour
+
+// Quota names, reported as the quota label of the quota_exceeded_total metric.
+const (
+	quotaClaims    = "claims"
+	quotaIssuances = "issuances"
+)
+
+// namespaceQuota holds the limits of a namespace; 0 means unlimited.
+type namespaceQuota struct {
+	maxClaims           int32
+	maxIssuancesPerHour int32
+}
+
+// quotaViolation describes which quota a claim exceeds.
+type quotaViolation struct {
+	// quota is quotaClaims or quotaIssuances.
+	quota   string
+	reason  string
+	message string
+	// retryAfter is when the issuance rate allows the claim again; zero for
+	// the claim limit, which is re-evaluated when claims are deleted.
+	retryAfter time.Duration
+}
+
+// resolveQuota returns the limits of namespace: the operator's defaults,
+// overridden by the lowest limits of the IdentityPolicies selecting the
+// namespace, overridden by the namespace's own annotations.
+func (r *IdentityClaimReconciler) resolveQuota(ctx context.Context, namespace string) (namespaceQuota, error) {
+	log := logf.FromContext(ctx)
+
+	quota := namespaceQuota{
+		maxClaims:           r.MaxClaimsPerNamespace,
+		maxIssuancesPerHour: r.MaxIssuancesPerHour,
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return quota, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	policyQuota, err := policy.Quota(ctx, r.Client, ns)
+	if err != nil {
+		return quota, err
+	}
+	if policyQuota.MaxClaims != nil {
+		quota.maxClaims = *policyQuota.MaxClaims
+	}
+	if policyQuota.MaxIssuancesPerHour != nil {
+		quota.maxIssuancesPerHour = *policyQuota.MaxIssuancesPerHour
+	}
+
+	for annotation, limit := range map[string]*int32{
+		identityv1alpha1.MaxClaimsAnnotation:           &quota.maxClaims,
+		identityv1alpha1.MaxIssuancesPerHourAnnotation: &quota.maxIssuancesPerHour,
+	} {
+		value, ok := ns.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || parsed < 0 {
+			log.Info("Ignoring invalid quota annotation", "namespace", namespace, "annotation", annotation, "value", value)
+			continue
+		}
+		*limit = int32(parsed)
+	}
+	return quota, nil
+}
+
+// checkQuota reports whether the claim would exceed its namespace's quota.
+// The oldest claims of a namespace are within the claim limit. The issuance
+// rate holds back claims whose reconcile would issue a certificate, counting
+// the namespace's issuance ledger; cert-manager's renewals can't be delayed
+// but count toward it. An issuance the rate allows is recorded in the ledger
+// right away, so claims reconciled before cert-manager requests their
+// certificates can't exceed the rate together.
+func (r *IdentityClaimReconciler) checkQuota(ctx context.Context, claim *identityv1alpha1.IdentityClaim, issuing bool) (*quotaViolation, error) {
+	quota, err := r.resolveQuota(ctx, claim.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if quota.maxClaims > 0 {
+		claims := &identityv1alpha1.IdentityClaimList{}
+		if err := r.List(ctx, claims, client.InNamespace(claim.Namespace)); err != nil {
+			return nil, fmt.Errorf("failed to list IdentityClaims: %w", err)
+		}
+		var older int32
+		for i := range claims.Items {
+			other := &claims.Items[i]
+			if other.UID != claim.UID && other.DeletionTimestamp.IsZero() && isOlderClaim(other, claim) {
+				older++
+			}
+		}
+		if older >= quota.maxClaims {
+			return &quotaViolation{
+				quota:  quotaClaims,
+				reason: "ClaimLimit",
+				message: fmt.Sprintf("Namespace %s allows %d IdentityClaim(s) and %d older claim(s) exist",
+					claim.Namespace, quota.maxClaims, older),
+			}, nil
+		}
+	}
+
+	if quota.maxIssuancesPerHour > 0 && issuing {
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, client.ObjectKey{Name: claim.Namespace}, ns); err != nil {
+			return nil, fmt.Errorf("failed to get namespace %s: %w", claim.Namespace, err)
+		}
+		now := time.Now()
+		ledger := issuances(ctx, ns, now)
+		// A pending issuance of the claim itself is the one being decided on
+		pending := pendingIssuancePrefix + string(claim.UID)
+		delete(ledger, pending)
+		var oldest time.Time
+		for _, at := range ledger {
+			if oldest.IsZero() || at.Time.Before(oldest) {
+				oldest = at.Time
+			}
+		}
+		if issued := int32(len(ledger)); issued >= quota.maxIssuancesPerHour {
+			return &quotaViolation{
+				quota:  quotaIssuances,
+				reason: "IssuanceRateLimit",
+				message: fmt.Sprintf("Namespace %s allows %d issuance(s) per hour and %d happened in the last hour",
+					claim.Namespace, quota.maxIssuancesPerHour, issued),
+				retryAfter: oldest.Add(issuanceWindow).Sub(now),
+			}, nil
+		}
+		ledger[pending] = metav1.NewTime(now)
+		if err := recordIssuances(ctx, r.Client, ns, ledger); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// issuesCertificate reports whether reconciling the claim makes cert-manager
+// issue a certificate: creating its Certificate, changing the Certificate's
+// spec to the desired one, or rotating it.
+func (r *IdentityClaimReconciler) issuesCertificate(ctx context.Context, claim *identityv1alpha1.IdentityClaim,
+	spec certmanagerv1.CertificateSpec) (bool, error) {
+	cert := &certmanagerv1.Certificate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}, cert); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return pendingRotation(ctx, claim) || !equality.Semantic.DeepEqual(cert.Spec, spec), nil
+}
+
+// findClaimsForNamespace maps a Namespace to its IdentityClaims, whose
+// policies and quota depend on the namespace's labels and annotations.
+func (r *IdentityClaimReconciler) findClaimsForNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims, client.InNamespace(obj.GetName())); err != nil {
+		log.Error(err, "failed to list IdentityClaims for namespace", "namespace", obj.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(claims.Items))
+	for _, claim := range claims.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// renewalJitterPercent bounds the jitter added to renewBefore, as a percentage
+// of the time between issuance and the unjittered renewal.
+const renewalJitterPercent = 10
+
+// validateRenewBefore checks the claim's renewBefore, before jitter, against
+// its TTL and cert-manager's minimum. The CRD enforces the same rules for
+// spec.renewBefore; renewBeforePercentage and the default are only known here.
+func validateRenewBefore(claim *identityv1alpha1.IdentityClaim, ttl time.Duration) error {
+	base := baseRenewBefore(claim, ttl)
+	if claim.Spec.RenewBefore != nil && base >= ttl {
+		return fmt.Errorf("renewBefore must be shorter than ttl %s, got %s", ttl, base)
+	}
+	if base < certmanagerv1.MinimumRenewBefore {
+		return fmt.Errorf("renewBefore must be at least %s, got %s for ttl %s", certmanagerv1.MinimumRenewBefore, base, ttl)
+	}
+	return nil
+}
+
+// baseRenewBefore returns spec.renewBefore, spec.renewBeforePercentage of ttl,
+// or a third of ttl. The default is raised to cert-manager's minimum for TTLs
+// too short for a third of them to reach it, as long as that is shorter than
+// the TTL.
+func baseRenewBefore(claim *identityv1alpha1.IdentityClaim, ttl time.Duration) time.Duration {
+	switch {
+	case claim.Spec.RenewBefore != nil:
+		return claim.Spec.RenewBefore.Duration
+	case claim.Spec.RenewBeforePercentage != nil:
+		return ttl * time.Duration(*claim.Spec.RenewBeforePercentage) / 100
+	}
+	if ttl/3 < certmanagerv1.MinimumRenewBefore && certmanagerv1.MinimumRenewBefore < ttl {
+		return certmanagerv1.MinimumRenewBefore
+	}
+	return ttl / 3
+}
+
+// renewBefore returns how long before expiry the claim's certificate is renewed,
+// baseRenewBefore plus up to renewalJitterPercent of the remaining lifetime so
+// claims created together don't renew in the same second. The jitter is
+// derived from the claim's UID, so the Certificate spec doesn't change between
+// reconciles.
+func renewBefore(claim *identityv1alpha1.IdentityClaim, ttl time.Duration) time.Duration {
+	base := baseRenewBefore(claim, ttl)
+
+	window := (ttl - base) * renewalJitterPercent / 100
+	if window <= 0 {
+		return base
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(claim.UID))
+	return base + time.Duration(h.Sum64()%uint64(window)).Truncate(time.Second)
+}
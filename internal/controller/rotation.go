@@ -0,0 +1,131 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// defaultRotationReason is recorded for rotations requested without a reason.
+const defaultRotationReason = "Requested"
+
+// pendingRotation reports whether the claim's rotate-requested-at annotation
+// requests a rotation that hasn't been handled yet. Requests are ignored unless
+// their timestamp is later than the last handled one, so re-applying the same
+// or an older manifest doesn't rotate again.
+func pendingRotation(ctx context.Context, claim *identityv1alpha1.IdentityClaim) bool {
+	value, ok := claim.Annotations[identityv1alpha1.RotateRequestedAtAnnotation]
+	if !ok || value == claim.Status.LastRotationRequest {
+		return false
+	}
+	requested, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// The webhook rejects invalid timestamps; claims admitted without it are skipped
+		logf.FromContext(ctx).Info("Ignoring invalid rotation request", "annotation",
+			identityv1alpha1.RotateRequestedAtAnnotation, "value", value)
+		return false
+	}
+	if claim.Status.LastRotationRequest != "" {
+		handled, err := time.Parse(time.RFC3339, claim.Status.LastRotationRequest)
+		if err == nil && !requested.After(handled) {
+			return false
+		}
+	}
+	return true
+}
+
+// rotate makes cert-manager re-issue the claim's certificate with a new private
+// key and records the rotation in the claim's status. Certificates that rotate
+// their key on every issuance are re-issued through cert-manager's manual
+// trigger, which replaces the Secret in place. Otherwise cert-manager would
+// reuse the key, so the Secret is deleted instead; running pods keep the files
+// they mounted until cert-manager writes the new Secret. It returns false when
+// the certificate is already being issued, since that issuance may have
+// started before the request, and when there was no issued Secret to delete.
+func (r *IdentityClaimReconciler) rotate(ctx context.Context, claim *identityv1alpha1.IdentityClaim, cert *certmanagerv1.Certificate) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	if certificateIssuing(cert) {
+		return false, nil
+	}
+
+	if key := cert.Spec.PrivateKey; key != nil && key.RotationPolicy == certmanagerv1.RotationPolicyAlways {
+		log.Info("Triggering certificate re-issuance", "name", cert.Name)
+		setIssuingCondition(cert)
+		if err := r.Status().Update(ctx, cert); err != nil {
+			return false, err
+		}
+	} else {
+		log.Info("Deleting identity Secret to rotate its private key", "name", claim.Status.SecretName)
+		deleted, err := r.deleteSecret(ctx, claim)
+		if err != nil || !deleted {
+			// Without the Secret cert-manager issues anew; the request is handled once it is back
+			return false, err
+		}
+	}
+
+	reason := claim.Annotations[identityv1alpha1.RotateReasonAnnotation]
+	if reason == "" {
+		reason = defaultRotationReason
+	}
+	now := metav1.Now()
+	claim.Status.LastRotationTime = &now
+	claim.Status.LastRotationReason = reason
+	claim.Status.LastRotationRequest = claim.Annotations[identityv1alpha1.RotateRequestedAtAnnotation]
+	r.recordEvent(claim, cert, corev1.EventTypeNormal, "Rotated", "Rotate",
+		"Re-issuing Certificate %s with a new private key: %s", cert.Name, reason)
+	return true, nil
+}
+
+// certificateIssuing reports whether cert-manager is issuing the Certificate.
+func certificateIssuing(cert *certmanagerv1.Certificate) bool {
+	for _, cond := range cert.Status.Conditions {
+		if cond.Type == certmanagerv1.CertificateConditionIssuing && cond.Status == cmmeta.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// setIssuingCondition marks the Certificate for re-issuance, as cmctl renew does.
+func setIssuingCondition(cert *certmanagerv1.Certificate) {
+	now := metav1.Now()
+	issuing := certmanagerv1.CertificateCondition{
+		Type:               certmanagerv1.CertificateConditionIssuing,
+		Status:             cmmeta.ConditionTrue,
+		Reason:             "ManuallyTriggered",
+		Message:            "Certificate re-issuance requested by IdentityClaim rotation",
+		LastTransitionTime: &now,
+		ObservedGeneration: cert.Generation,
+	}
+	for i, cond := range cert.Status.Conditions {
+		if cond.Type == certmanagerv1.CertificateConditionIssuing {
+			cert.Status.Conditions[i] = issuing
+			return
+		}
+	}
+	cert.Status.Conditions = append(cert.Status.Conditions, issuing)
+}
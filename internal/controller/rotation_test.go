@@ -0,0 +1,203 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// The controller suite's envtest doesn't install cert-manager's CRDs, so
+// rotations are reconciled against a fake client holding an issued claim.
+var _ = Describe("IdentityClaim rotation", func() {
+	const (
+		resourceName = "rotation-claim"
+		secretName   = "rotation-claim-identity"
+		requestedAt  = "2026-01-02T15:04:05Z"
+	)
+	ctx := context.Background()
+	nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+	secretNN := types.NamespacedName{Name: secretName, Namespace: "default"}
+
+	// newReconciler returns a reconciler for a Ready claim whose Certificate
+	// and Secret have been issued, with the given private key settings and
+	// annotations.
+	newReconciler := func(key *identityv1alpha1.PrivateKey, annotations map[string]string) *IdentityClaimReconciler {
+		claim := &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        resourceName,
+				Namespace:   "default",
+				UID:         "00000000-0000-0000-0000-000000000020",
+				Finalizers:  []string{finalizerName},
+				Annotations: annotations,
+			},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector:   metav1.LabelSelector{MatchLabels: map[string]string{"app": "rotation"}},
+				TTL:        metav1.Duration{Duration: 1 * time.Hour},
+				PrivateKey: key,
+			},
+			Status: identityv1alpha1.IdentityClaimStatus{
+				Phase:      identityv1alpha1.PhaseReady,
+				SecretName: secretName,
+			},
+		}
+		cert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+			Status: certmanagerv1.CertificateStatus{
+				Conditions: []certmanagerv1.CertificateCondition{{
+					Type:   certmanagerv1.CertificateConditionReady,
+					Status: cmmeta.ConditionTrue,
+				}},
+			},
+		}
+		Expect(controllerutil.SetControllerReference(claim, cert, scheme.Scheme)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Name:      "rotation-pod",
+					Namespace: "default",
+					Labels:    map[string]string{"app": "rotation"},
+				}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+					Name:        secretName,
+					Namespace:   "default",
+					Annotations: map[string]string{certmanagerv1.CertificateNameKey: secretName},
+				}},
+				claim, cert).
+			WithStatusSubresource(claim, cert).
+			Build()
+		return &IdentityClaimReconciler{Client: c, Scheme: scheme.Scheme}
+	}
+
+	isIssuing := func(c client.Client) bool {
+		cert := &certmanagerv1.Certificate{}
+		Expect(c.Get(ctx, secretNN, cert)).To(Succeed())
+		for _, cond := range cert.Status.Conditions {
+			if cond.Type == certmanagerv1.CertificateConditionIssuing {
+				return cond.Status == cmmeta.ConditionTrue
+			}
+		}
+		return false
+	}
+
+	It("should trigger re-issuance once per requested timestamp", func() {
+		r := newReconciler(
+			&identityv1alpha1.PrivateKey{RotationPolicy: identityv1alpha1.RotationPolicyAlways},
+			map[string]string{
+				identityv1alpha1.RotateRequestedAtAnnotation: requestedAt,
+				identityv1alpha1.RotateReasonAnnotation:      "key leaked",
+			})
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isIssuing(r.Client)).To(BeTrue())
+		Expect(r.Get(ctx, secretNN, &corev1.Secret{})).To(Succeed())
+
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(r.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseIssuing))
+		Expect(claim.Status.LastRotationTime).NotTo(BeNil())
+		Expect(claim.Status.LastRotationReason).To(Equal("key leaked"))
+		Expect(claim.Status.LastRotationRequest).To(Equal(requestedAt))
+		rotatedAt := claim.Status.LastRotationTime
+
+		By("reconciling again after cert-manager re-issued the certificate")
+		cert := &certmanagerv1.Certificate{}
+		Expect(r.Get(ctx, secretNN, cert)).To(Succeed())
+		cert.Status.Conditions = cert.Status.Conditions[:1]
+		Expect(r.Status().Update(ctx, cert)).To(Succeed())
+
+		_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isIssuing(r.Client)).To(BeFalse())
+		Expect(r.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseReady))
+		Expect(claim.Status.LastRotationTime).To(Equal(rotatedAt))
+	})
+
+	It("should stay Issuing while the triggered re-issuance is in progress", func() {
+		r := newReconciler(
+			&identityv1alpha1.PrivateKey{RotationPolicy: identityv1alpha1.RotationPolicyAlways},
+			map[string]string{identityv1alpha1.RotateRequestedAtAnnotation: requestedAt})
+
+		for range 2 {
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+		}
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(r.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseIssuing))
+	})
+
+	It("should not record a rotation without an issued Secret to delete", func() {
+		r := newReconciler(nil, map[string]string{identityv1alpha1.RotateRequestedAtAnnotation: requestedAt})
+		Expect(r.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"}})).To(Succeed())
+
+		result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(r.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.LastRotationTime).To(BeNil())
+		Expect(claim.Status.LastRotationRequest).To(BeEmpty())
+	})
+
+	It("should delete the Secret when cert-manager could reuse the key", func() {
+		r := newReconciler(nil, map[string]string{identityv1alpha1.RotateRequestedAtAnnotation: requestedAt})
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(r.Get(ctx, secretNN, &corev1.Secret{}))).To(BeTrue())
+
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(r.Get(ctx, nn, claim)).To(Succeed())
+		Expect(claim.Status.Phase).To(Equal(identityv1alpha1.PhaseIssuing))
+		Expect(claim.Status.LastRotationReason).To(Equal(defaultRotationReason))
+	})
+
+	It("should ignore requests not later than the last rotation", func() {
+		r := newReconciler(nil, map[string]string{identityv1alpha1.RotateRequestedAtAnnotation: "2025-12-31T00:00:00Z"})
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(r.Get(ctx, nn, claim)).To(Succeed())
+		claim.Status.LastRotationRequest = requestedAt
+		Expect(r.Status().Update(ctx, claim)).To(Succeed())
+
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(ctx, secretNN, &corev1.Secret{})).To(Succeed())
+	})
+})
@@ -0,0 +1,128 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// applySecretSpec propagates spec.secret to the Certificate's secret template,
+// keystores and additional output formats.
+func applySecretSpec(spec *certmanagerv1.CertificateSpec, secret *identityv1alpha1.SecretSpec) {
+	if secret == nil {
+		return
+	}
+
+	if len(secret.Labels) > 0 || len(secret.Annotations) > 0 {
+		spec.SecretTemplate = &certmanagerv1.CertificateSecretTemplate{
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		}
+	}
+
+	if len(secret.Keystores) > 0 && secret.PasswordSecretRef != nil {
+		password := cmmeta.SecretKeySelector{
+			LocalObjectReference: cmmeta.LocalObjectReference{Name: secret.PasswordSecretRef.Name},
+			Key:                  secret.PasswordSecretRef.Key,
+		}
+		spec.Keystores = &certmanagerv1.CertificateKeystores{}
+		for _, keystore := range secret.Keystores {
+			switch keystore {
+			case identityv1alpha1.KeystorePKCS12:
+				spec.Keystores.PKCS12 = &certmanagerv1.PKCS12Keystore{Create: true, PasswordSecretRef: password}
+			case identityv1alpha1.KeystoreJKS:
+				spec.Keystores.JKS = &certmanagerv1.JKSKeystore{Create: true, PasswordSecretRef: password}
+			}
+		}
+	}
+
+	for _, format := range secret.AdditionalOutputFormats {
+		spec.AdditionalOutputFormats = append(spec.AdditionalOutputFormats,
+			certmanagerv1.CertificateAdditionalOutputFormat{Type: certmanagerv1.CertificateOutputFormatType(format)})
+	}
+}
+
+// secretKeys returns the data keys cert-manager writes to the identity Secret.
+func secretKeys(secret *identityv1alpha1.SecretSpec) []string {
+	keys := []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, cmmeta.TLSCAKey}
+	if secret == nil {
+		return keys
+	}
+
+	if secret.PasswordSecretRef != nil {
+		for _, keystore := range secret.Keystores {
+			switch keystore {
+			case identityv1alpha1.KeystorePKCS12:
+				keys = append(keys, certmanagerv1.PKCS12SecretKey, certmanagerv1.PKCS12TruststoreKey)
+			case identityv1alpha1.KeystoreJKS:
+				keys = append(keys, certmanagerv1.JKSSecretKey, certmanagerv1.JKSTruststoreKey)
+			}
+		}
+	}
+	for _, format := range secret.AdditionalOutputFormats {
+		switch format {
+		case identityv1alpha1.OutputFormatCombinedPEM:
+			keys = append(keys, certmanagerv1.CertificateOutputFormatCombinedPEMKey)
+		case identityv1alpha1.OutputFormatDER:
+			keys = append(keys, certmanagerv1.CertificateOutputFormatDERKey)
+		}
+	}
+	return keys
+}
+
+// findSecretConflict describes why the claim's Secret name is taken, or returns
+// an empty string when it is free. A Certificate must be controlled by the claim;
+// a Secret must have been written by cert-manager for a Certificate of that name,
+// which the Certificate check guarantees is the claim's own.
+func (r *IdentityClaimReconciler) findSecretConflict(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (string, error) {
+	key := client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}
+
+	cert := &certmanagerv1.Certificate{}
+	if err := r.Get(ctx, key, cert); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+	} else if !metav1.IsControlledBy(cert, claim) {
+		return fmt.Sprintf("Certificate %s already exists and is not owned by this IdentityClaim", key.Name), nil
+	}
+
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if secret.Annotations[certmanagerv1.CertificateNameKey] != key.Name {
+		return fmt.Sprintf("Secret %s already exists and was not issued for this IdentityClaim", key.Name), nil
+	}
+	return "", nil
+}
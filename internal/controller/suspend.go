@@ -0,0 +1,63 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// reconcileSuspended marks the claim as suspended and leaves everything else as
+// it is. The status is only written when the claim becomes suspended, so a
+// suspended claim sees no churn. cert-manager has no way to pause a
+// Certificate, so it still renews the certificate when it is due.
+func (r *IdentityClaimReconciler) reconcileSuspended(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (ctrl.Result, error) {
+	if meta.IsStatusConditionTrue(claim.Status.Conditions, identityv1alpha1.ConditionSuspended) {
+		return ctrl.Result{}, nil
+	}
+
+	logf.FromContext(ctx).Info("Reconciliation suspended")
+	r.setCondition(claim, identityv1alpha1.ConditionSuspended, metav1.ConditionTrue,
+		"Suspended", "Reconciliation is suspended by spec.suspend")
+	r.recordEvent(claim, nil, corev1.EventTypeNormal, "Suspended", "Reconcile",
+		"Reconciliation suspended, Certificate %s is left as it is", claim.Status.SecretName)
+	if err := r.Status().Update(ctx, claim); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Unsetting spec.suspend changes the generation, which triggers a new reconcile.
+	return ctrl.Result{}, nil
+}
+
+// resume removes the Suspended condition of a claim whose spec.suspend was
+// unset. The rest of the reconcile brings the claim up to date and writes the
+// status.
+func (r *IdentityClaimReconciler) resume(ctx context.Context, claim *identityv1alpha1.IdentityClaim) {
+	if meta.FindStatusCondition(claim.Status.Conditions, identityv1alpha1.ConditionSuspended) == nil {
+		return
+	}
+
+	logf.FromContext(ctx).Info("Reconciliation resumed")
+	meta.RemoveStatusCondition(&claim.Status.Conditions, identityv1alpha1.ConditionSuspended)
+	r.recordEvent(claim, nil, corev1.EventTypeNormal, "Resumed", "Reconcile", "Reconciliation resumed")
+}
@@ -0,0 +1,499 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+const (
+	// TrustBundleLabel marks the ConfigMaps the TrustBundleReconciler manages.
+	TrustBundleLabel = "identity.cluster.local/trust-bundle"
+	// DefaultTrustBundleOverlap is how long CA certificates stay in the trust
+	// bundle after their issuer stopped using them.
+	DefaultTrustBundleOverlap = 24 * time.Hour
+
+	// rootsKey is the key of the state ConfigMap's data holding the CA
+	// certificates of the bundle.
+	rootsKey = "roots.json"
+	// trustBundleResync is how often the bundle is rebuilt without a watch
+	// event; issuer CA Secrets aren't watched to keep them out of the cache.
+	trustBundleResync = 5 * time.Minute
+	// defaultClusterResourceNamespace is where cert-manager reads the Secrets
+	// of ClusterIssuers by default.
+	defaultClusterResourceNamespace = "cert-manager"
+)
+
+// trustBundleRequest is the only request of the TrustBundleReconciler: every
+// namespace gets the same bundle.
+var trustBundleRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "trust-bundle"}}
+
+// TrustBundleReconciler writes a ConfigMap with the CA certificates of every
+// issuer referenced by IdentityClaims into each namespace that has claims, so
+// workloads can verify peers identified by other issuers.
+type TrustBundleReconciler struct {
+	client.Client
+	// APIReader reads Secrets and ConfigMaps directly from the API server, so
+	// the manager doesn't cache every one in the cluster. SetupWithManager
+	// provides one from the manager when unset; the client is used otherwise.
+	APIReader client.Reader
+	// Name is the name of the ConfigMaps.
+	Name string
+	// State is the ConfigMap, in the operator's namespace, recording the CA
+	// certificates of the bundle and when those no issuer uses anymore were
+	// last seen. The distributed ConfigMaps are never read back, since anyone
+	// able to write ConfigMaps in a namespace could add certificates to them.
+	State types.NamespacedName
+	// DefaultIssuerName and DefaultIssuerKind are the IdentityClaimReconciler's
+	// default issuer, used by claims without spec.issuerRef.
+	DefaultIssuerName string
+	DefaultIssuerKind string
+	// ClusterResourceNamespace is where cert-manager reads the Secrets of
+	// ClusterIssuers; cert-manager by default.
+	ClusterResourceNamespace string
+	// Overlap is how long a CA certificate stays in the bundle after its
+	// issuer stopped using it, so peers keep trusting certificates it signed
+	// until they are renewed.
+	Overlap time.Duration
+}
+
+// +kubebuilder:rbac:groups=identity.cluster.local,resources=identityclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cert-manager.io,resources=issuers;clusterissuers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;create;update;delete
+
+// Reconcile rebuilds the trust bundle and writes it into every namespace with
+// IdentityClaims, deleting it from namespaces that no longer have any.
+func (r *TrustBundleReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := r.List(ctx, claims); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list IdentityClaims: %w", err)
+	}
+	namespaces := make(map[string]bool)
+	current := make(map[string]*x509.Certificate)
+	done := make(map[issuerKey]bool)
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		namespaces[claim.Namespace] = true
+		certs, err := r.caCertificates(ctx, claim, done)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		for _, cert := range certs {
+			current[fingerprint(cert)] = cert
+		}
+	}
+
+	stored, state, err := r.readState(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	now := time.Now()
+	retained := r.retainedRoots(ctx, stored, current, now)
+	// The state is written first, so roots dropped from the bundles below
+	// are remembered even if writing those fails.
+	if err := r.writeState(ctx, state, current, retained); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	requeueAfter := trustBundleResync
+	bundle := maps.Clone(current)
+	for fp, root := range retained {
+		bundle[fp] = root.cert
+		requeueAfter = min(requeueAfter, root.lastSeen.Add(r.Overlap).Sub(now))
+	}
+	var data bytes.Buffer
+	for _, fp := range slices.Sorted(maps.Keys(bundle)) {
+		_ = pem.Encode(&data, &pem.Block{Type: "CERTIFICATE", Bytes: bundle[fp].Raw})
+	}
+
+	for _, namespace := range slices.Sorted(maps.Keys(namespaces)) {
+		if err := r.apply(ctx, namespace, data.String()); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	existing := &corev1.ConfigMapList{}
+	if err := r.reader().List(ctx, existing, client.MatchingLabels{TrustBundleLabel: "true"}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list trust bundle ConfigMaps: %w", err)
+	}
+	for i := range existing.Items {
+		cm := &existing.Items[i]
+		if cm.Name != r.Name || namespaces[cm.Namespace] {
+			continue
+		}
+		log.Info("Deleting trust bundle of namespace without IdentityClaims", "namespace", cm.Namespace)
+		if err := r.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// issuerKey identifies an issuer; namespace is empty for ClusterIssuers.
+type issuerKey struct {
+	namespace string
+	ref       cmmeta.ObjectReference
+}
+
+// caCertificates returns the CA certificates of the claim's issuer, reading
+// each issuer once, as recorded in done. Only claims the operator issued for
+// count: a claim that isn't Ready, failed or is denied by an IdentityPolicy
+// could otherwise add any issuer its author creates to every namespace's
+// bundle. For issuers whose CA isn't part of their configuration, such as
+// ACME, Vault and external issuers, the ca.crt cert-manager wrote into the
+// claim's Secret is used, but only if the claim controls its Certificate, so a
+// Secret anyone could write with the Certificate's name doesn't count.
+func (r *TrustBundleReconciler) caCertificates(ctx context.Context, claim *identityv1alpha1.IdentityClaim, done map[issuerKey]bool) ([]*x509.Certificate, error) {
+	log := logf.FromContext(ctx)
+
+	key := issuerKey{namespace: claim.Namespace, ref: issuerRefFor(claim, r.DefaultIssuerName, r.DefaultIssuerKind)}
+	if key.ref.Kind == "ClusterIssuer" {
+		key.namespace = ""
+	}
+	if done[key] || !trustedClaim(claim) {
+		return nil, nil
+	}
+	if key.ref.Group == "cert-manager.io" {
+		certs, stated, err := r.issuerCA(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if stated {
+			done[key] = true
+			return certs, nil
+		}
+	}
+
+	if claim.Status.SecretName == "" {
+		return nil, nil
+	}
+	cert := &certmanagerv1.Certificate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}, cert); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Certificate %s: %w", claim.Status.SecretName, err)
+	}
+	if !metav1.IsControlledBy(cert, claim) || cert.Spec.SecretName != claim.Status.SecretName {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.reader().Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: claim.Status.SecretName}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Secret %s: %w", claim.Status.SecretName, err)
+	}
+	if secret.Annotations[certmanagerv1.CertificateNameKey] != claim.Status.SecretName || len(secret.Data[cmmeta.TLSCAKey]) == 0 {
+		return nil, nil
+	}
+	certs, err := parseCertificates(secret.Data[cmmeta.TLSCAKey])
+	if err != nil {
+		log.Info("Ignoring unreadable CA of IdentityClaim Secret", "namespace", claim.Namespace,
+			"secret", claim.Status.SecretName, "error", err.Error())
+		return nil, nil
+	}
+	done[key] = true
+	return certs, nil
+}
+
+// trustedClaim reports whether the claim is Ready and neither failed nor denied by
+// an IdentityPolicy since.
+func trustedClaim(claim *identityv1alpha1.IdentityClaim) bool {
+	return meta.IsStatusConditionTrue(claim.Status.Conditions, identityv1alpha1.ConditionReady) &&
+		claim.Status.Phase != identityv1alpha1.PhaseFailed &&
+		!meta.IsStatusConditionTrue(claim.Status.Conditions, identityv1alpha1.ConditionPolicyViolation)
+}
+
+// issuerCA returns the CA certificates of a cert-manager issuer, and whether
+// its configuration states them: a CA issuer's are read from its Secret, a
+// SelfSigned issuer has no CA to distribute, and a missing issuer issues
+// nothing.
+func (r *TrustBundleReconciler) issuerCA(ctx context.Context, key issuerKey) ([]*x509.Certificate, bool, error) {
+	log := logf.FromContext(ctx)
+
+	var issuer certmanagerv1.GenericIssuer = &certmanagerv1.Issuer{}
+	secretNamespace := key.namespace
+	if key.namespace == "" {
+		issuer = &certmanagerv1.ClusterIssuer{}
+		secretNamespace = r.ClusterResourceNamespace
+		if secretNamespace == "" {
+			secretNamespace = defaultClusterResourceNamespace
+		}
+	}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: key.namespace, Name: key.ref.Name}, issuer); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("failed to get %s %s: %w", key.ref.Kind, key.ref.Name, err)
+	}
+	spec := issuer.GetSpec()
+	if spec.CA == nil {
+		return nil, spec.SelfSigned != nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.reader().Get(ctx, client.ObjectKey{Namespace: secretNamespace, Name: spec.CA.SecretName}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("failed to get CA Secret of %s %s: %w", key.ref.Kind, key.ref.Name, err)
+	}
+	data := secret.Data[cmmeta.TLSCAKey]
+	if len(data) == 0 {
+		data = secret.Data[corev1.TLSCertKey]
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		log.Info("Ignoring unreadable CA Secret", "issuer", key.ref.Name, "secret", spec.CA.SecretName, "error", err.Error())
+	}
+	return certs, true, nil
+}
+
+// storedRoot is a CA certificate of the bundle as recorded in the state
+// ConfigMap, keyed by its SHA-256 fingerprint.
+type storedRoot struct {
+	// Certificate is PEM encoded.
+	Certificate string `json:"certificate"`
+	// LastSeen is when an issuer last used the certificate, unset while one
+	// still does.
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
+}
+
+// retainedRoot is a CA certificate of the bundle no issuer uses anymore.
+type retainedRoot struct {
+	cert     *x509.Certificate
+	lastSeen time.Time
+}
+
+// readState returns the CA certificates recorded in the state ConfigMap and
+// the ConfigMap, which is nil if it doesn't exist.
+func (r *TrustBundleReconciler) readState(ctx context.Context) (map[string]storedRoot, *corev1.ConfigMap, error) {
+	state := &corev1.ConfigMap{}
+	if err := r.reader().Get(ctx, r.State, state); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get trust bundle state %s: %w", r.State, err)
+	}
+	data, ok := state.Data[rootsKey]
+	if !ok {
+		return nil, state, nil
+	}
+	var stored map[string]storedRoot
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s in trust bundle state %s: %w", rootsKey, r.State, err)
+	}
+	return stored, state, nil
+}
+
+// retainedRoots returns the recorded certificates that aren't current, until
+// the overlap window since they were last seen has passed or they expire.
+// Certificates that just stopped being current were last seen now.
+func (r *TrustBundleReconciler) retainedRoots(ctx context.Context, stored map[string]storedRoot, current map[string]*x509.Certificate, now time.Time) map[string]retainedRoot {
+	log := logf.FromContext(ctx)
+
+	retained := make(map[string]retainedRoot)
+	for fp, root := range stored {
+		if _, ok := current[fp]; ok {
+			continue
+		}
+		certs, err := parseCertificates([]byte(root.Certificate))
+		if err != nil || len(certs) != 1 || fingerprint(certs[0]) != fp {
+			log.Info("Dropping unreadable CA certificate from trust bundle state", "fingerprint", fp)
+			continue
+		}
+		seen := now
+		if root.LastSeen != nil {
+			seen = root.LastSeen.Time
+		}
+		if now.Sub(seen) >= r.Overlap || now.After(certs[0].NotAfter) {
+			continue
+		}
+		retained[fp] = retainedRoot{cert: certs[0], lastSeen: seen}
+	}
+	return retained
+}
+
+// writeState records the current and retained certificates in the state
+// ConfigMap when they changed.
+func (r *TrustBundleReconciler) writeState(ctx context.Context, state *corev1.ConfigMap, current map[string]*x509.Certificate, retained map[string]retainedRoot) error {
+	stored := make(map[string]storedRoot, len(current)+len(retained))
+	for fp, cert := range current {
+		stored[fp] = storedRoot{Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))}
+	}
+	for fp, root := range retained {
+		lastSeen := metav1.NewTime(root.lastSeen)
+		stored[fp] = storedRoot{
+			Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.cert.Raw})),
+			LastSeen:    &lastSeen,
+		}
+	}
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	data := string(encoded)
+
+	if state == nil {
+		state = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: r.State.Name, Namespace: r.State.Namespace},
+			Data:       map[string]string{rootsKey: data},
+		}
+		err = r.Create(ctx, state)
+	} else {
+		if state.Data[rootsKey] == data {
+			return nil
+		}
+		if state.Data == nil {
+			state.Data = map[string]string{}
+		}
+		state.Data[rootsKey] = data
+		err = r.Update(ctx, state)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write trust bundle state %s: %w", r.State, err)
+	}
+	return nil
+}
+
+// apply writes the bundle into the namespace's ConfigMap. ConfigMaps of the
+// same name the operator didn't create are left alone.
+func (r *TrustBundleReconciler) apply(ctx context.Context, namespace, data string) error {
+	log := logf.FromContext(ctx)
+
+	cm := &corev1.ConfigMap{}
+	err := r.reader().Get(ctx, client.ObjectKey{Namespace: namespace, Name: r.Name}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.Name,
+				Namespace: namespace,
+				Labels:    map[string]string{TrustBundleLabel: "true"},
+			},
+			Data: map[string]string{cmmeta.TLSCAKey: data},
+		}
+		log.Info("Creating trust bundle", "namespace", namespace)
+		err = r.Create(ctx, cm)
+		if apierrors.IsNotFound(err) || apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause) {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get trust bundle of namespace %s: %w", namespace, err)
+	}
+	if cm.Labels[TrustBundleLabel] != "true" {
+		log.Info("Not overwriting ConfigMap the operator doesn't manage", "namespace", namespace, "configMap", r.Name)
+		return nil
+	}
+	if cm.Data[cmmeta.TLSCAKey] == data {
+		return nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[cmmeta.TLSCAKey] = data
+	log.Info("Updating trust bundle", "namespace", namespace)
+	return client.IgnoreNotFound(r.Update(ctx, cm))
+}
+
+// reader returns the APIReader, falling back to the client.
+func (r *TrustBundleReconciler) reader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
+// fingerprint returns the hex encoded SHA-256 digest of the certificate.
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseCertificates parses PEM encoded certificates.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TrustBundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{trustBundleRequest}
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		// Status changes matter too: they carry the claim's Secret name.
+		Watches(&identityv1alpha1.IdentityClaim{}, enqueue).
+		Watches(&certmanagerv1.Issuer{}, enqueue,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&certmanagerv1.ClusterIssuer{}, enqueue,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("trustbundle").
+		Complete(r)
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// The controller suite's envtest doesn't install cert-manager's CRDs, so trust
+// bundles are reconciled against a fake client holding issuers and claims.
+var _ = Describe("TrustBundle Controller", func() {
+	const bundleName = "identity-trust-bundle"
+	stateKey := types.NamespacedName{Namespace: "identity-system", Name: "trust-bundle-state"}
+	ctx := context.Background()
+
+	// newCA returns a PEM encoded self-signed CA certificate.
+	newCA := func(commonName string) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(time.Now().UnixNano()),
+			Subject:               pkix.Name{CommonName: commonName},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(24 * time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	// subjects returns the common names of the bundle in namespace, or nil
+	// when it doesn't exist.
+	subjects := func(c client.Client, namespace string) []string {
+		cm := &corev1.ConfigMap{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: bundleName}, cm)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cm.Labels).To(HaveKeyWithValue(TrustBundleLabel, "true"))
+		certs, err := parseCertificates([]byte(cm.Data[cmmeta.TLSCAKey]))
+		Expect(err).NotTo(HaveOccurred())
+		names := []string{}
+		for _, cert := range certs {
+			names = append(names, cert.Subject.CommonName)
+		}
+		return names
+	}
+
+	newClaim := func(namespace, name string, issuer *identityv1alpha1.IssuerReference) *identityv1alpha1.IdentityClaim {
+		return &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(namespace + "-" + name)},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector:  metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+				TTL:       metav1.Duration{Duration: time.Hour},
+				IssuerRef: issuer,
+			},
+			Status: identityv1alpha1.IdentityClaimStatus{
+				SecretName: name + "-identity",
+				Conditions: []metav1.Condition{{Type: identityv1alpha1.ConditionReady, Status: metav1.ConditionTrue}},
+			},
+		}
+	}
+
+	// newIssued returns the Certificate the claim controls and the Secret
+	// cert-manager issued for it, holding caPEM.
+	newIssued := func(claim *identityv1alpha1.IdentityClaim, caPEM []byte) (*certmanagerv1.Certificate, *corev1.Secret) {
+		name := claim.Status.SecretName
+		cert := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: claim.Namespace},
+			Spec:       certmanagerv1.CertificateSpec{SecretName: name},
+		}
+		Expect(controllerutil.SetControllerReference(claim, cert, scheme.Scheme)).To(Succeed())
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   claim.Namespace,
+				Annotations: map[string]string{certmanagerv1.CertificateNameKey: name},
+			},
+			Data: map[string][]byte{cmmeta.TLSCAKey: caPEM},
+		}
+		return cert, secret
+	}
+
+	var (
+		c          client.Client
+		reconciler *TrustBundleReconciler
+		caSecret   *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		caSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "cert-manager"},
+			Data: map[string][]byte{
+				corev1.TLSCertKey: newCA("intermediate"),
+				cmmeta.TLSCAKey:   newCA("root-1"),
+			},
+		}
+		pca := newClaim("team-b", "pca", &identityv1alpha1.IssuerReference{
+			Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io",
+		})
+		pcaCert, pcaSecret := newIssued(pca, newCA("private-ca"))
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				&certmanagerv1.ClusterIssuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "ca-issuer"},
+					Spec: certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{
+						CA: &certmanagerv1.CAIssuer{SecretName: "root-ca"},
+					}},
+				},
+				&certmanagerv1.Issuer{
+					ObjectMeta: metav1.ObjectMeta{Name: "selfsigned", Namespace: "team-b"},
+					Spec: certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{
+						SelfSigned: &certmanagerv1.SelfSignedIssuer{},
+					}},
+				},
+				caSecret,
+				newClaim("team-a", "api", nil),
+				newClaim("team-b", "self", &identityv1alpha1.IssuerReference{Name: "selfsigned", Kind: "Issuer"}),
+				pca, pcaCert, pcaSecret,
+			).
+			Build()
+		reconciler = &TrustBundleReconciler{
+			Client:            c,
+			Name:              bundleName,
+			State:             stateKey,
+			DefaultIssuerName: "ca-issuer",
+			Overlap:           time.Hour,
+		}
+	})
+
+	It("should write the CA certificates of every issuer into each namespace with claims", func() {
+		result, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(trustBundleResync))
+
+		for _, namespace := range []string{"team-a", "team-b"} {
+			Expect(subjects(c, namespace)).To(ConsistOf("root-1", "private-ca"))
+		}
+		Expect(subjects(c, "team-c")).To(BeNil())
+	})
+
+	It("should keep a replaced CA certificate during the overlap window", func() {
+		_, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("rotating the CA issuer's Secret")
+		caSecret.Data[cmmeta.TLSCAKey] = newCA("root-2")
+		Expect(c.Update(ctx, caSecret)).To(Succeed())
+		result, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("<=", time.Hour))
+		Expect(subjects(c, "team-a")).To(ConsistOf("root-1", "root-2", "private-ca"))
+
+		state := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, stateKey, state)).To(Succeed())
+		var stored map[string]storedRoot
+		Expect(json.Unmarshal([]byte(state.Data[rootsKey]), &stored)).To(Succeed())
+		Expect(stored).To(HaveLen(3))
+		Expect(stored).To(HaveEach(HaveField("LastSeen", Or(BeNil(), HaveField("Time", BeTemporally("~", time.Now(), time.Minute))))))
+
+		By("a namespace getting its first claim during the window")
+		Expect(c.Create(ctx, newClaim("team-c", "web", nil))).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(c, "team-c")).To(ConsistOf("root-1", "root-2", "private-ca"))
+
+		By("letting the overlap window pass")
+		Expect(c.Get(ctx, stateKey, state)).To(Succeed())
+		Expect(json.Unmarshal([]byte(state.Data[rootsKey]), &stored)).To(Succeed())
+		for fp, root := range stored {
+			if root.LastSeen != nil {
+				root.LastSeen = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+				stored[fp] = root
+			}
+		}
+		encoded, err := json.Marshal(stored)
+		Expect(err).NotTo(HaveOccurred())
+		state.Data[rootsKey] = string(encoded)
+		Expect(c.Update(ctx, state)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(c, "team-a")).To(ConsistOf("root-2", "private-ca"))
+		Expect(c.Get(ctx, stateKey, state)).To(Succeed())
+		stored = nil
+		Expect(json.Unmarshal([]byte(state.Data[rootsKey]), &stored)).To(Succeed())
+		Expect(stored).To(HaveLen(2))
+	})
+
+	It("should not spread certificates added to a namespace's bundle", func() {
+		_, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "team-b", Name: bundleName}, cm)).To(Succeed())
+		cm.Data[cmmeta.TLSCAKey] += string(newCA("forged"))
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, namespace := range []string{"team-a", "team-b"} {
+			Expect(subjects(c, namespace)).To(ConsistOf("root-1", "private-ca"))
+		}
+	})
+
+	It("should not distribute the CA of an issuer only used by claims that weren't issued", func() {
+		Expect(c.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant-ca", Namespace: "team-c"},
+			Data:       map[string][]byte{cmmeta.TLSCAKey: newCA("tenant-ca")},
+		})).To(Succeed())
+		Expect(c.Create(ctx, &certmanagerv1.Issuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "team-c"},
+			Spec: certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{
+				CA: &certmanagerv1.CAIssuer{SecretName: "tenant-ca"},
+			}},
+		})).To(Succeed())
+		denied := newClaim("team-c", "denied", &identityv1alpha1.IssuerReference{Name: "tenant", Kind: "Issuer"})
+		denied.Status.Phase = identityv1alpha1.PhaseFailed
+		denied.Status.Conditions = []metav1.Condition{
+			{Type: identityv1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "PolicyViolation"},
+			{Type: identityv1alpha1.ConditionPolicyViolation, Status: metav1.ConditionTrue, Reason: "DeniedByPolicy"},
+		}
+		Expect(c.Create(ctx, denied)).To(Succeed())
+		pending := newClaim("team-c", "pending", &identityv1alpha1.IssuerReference{Name: "tenant", Kind: "Issuer"})
+		pending.Status.Conditions = nil
+		Expect(c.Create(ctx, pending)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		for _, namespace := range []string{"team-a", "team-b", "team-c"} {
+			Expect(subjects(c, namespace)).To(ConsistOf("root-1", "private-ca"))
+		}
+	})
+
+	It("should only take a CA from the Secrets of Ready claims controlling their Certificate", func() {
+		external := &identityv1alpha1.IssuerReference{Name: "vault", Kind: "VaultIssuer", Group: "vault.example.com"}
+
+		By("a claim that isn't Ready")
+		pending := newClaim("team-c", "pending", external)
+		pending.Status.Conditions = nil
+		cert, secret := newIssued(pending, newCA("pending-ca"))
+		Expect(c.Create(ctx, pending)).To(Succeed())
+		Expect(c.Create(ctx, cert)).To(Succeed())
+		Expect(c.Create(ctx, secret)).To(Succeed())
+
+		By("a Secret named after a Certificate the claim doesn't control")
+		forged := newClaim("team-c", "forged", external)
+		cert, secret = newIssued(forged, newCA("forged-ca"))
+		cert.OwnerReferences = nil
+		Expect(c.Create(ctx, forged)).To(Succeed())
+		Expect(c.Create(ctx, cert)).To(Succeed())
+		Expect(c.Create(ctx, secret)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(c, "team-c")).To(ConsistOf("root-1", "private-ca"))
+
+		By("a Ready claim of the same issuer")
+		ready := newClaim("team-c", "ready", external)
+		cert, secret = newIssued(ready, newCA("vault-ca"))
+		Expect(c.Create(ctx, ready)).To(Succeed())
+		Expect(c.Create(ctx, cert)).To(Succeed())
+		Expect(c.Create(ctx, secret)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(c, "team-c")).To(ConsistOf("root-1", "private-ca", "vault-ca"))
+	})
+
+	It("should delete the bundle of a namespace whose last claim is gone", func() {
+		Expect(c.Create(ctx, newClaim("team-c", "web", nil))).To(Succeed())
+		Expect(c.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: bundleName, Namespace: "team-c"},
+			Data:       map[string]string{cmmeta.TLSCAKey: "not ours"},
+		})).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "api"}, claim)).To(Succeed())
+		Expect(c.Delete(ctx, claim)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, trustBundleRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(subjects(c, "team-a")).To(BeNil())
+		Expect(subjects(c, "team-b")).To(ConsistOf("root-1", "private-ca"))
+		unmanaged := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "team-c", Name: bundleName}, unmanaged)).To(Succeed())
+		Expect(unmanaged.Data[cmmeta.TLSCAKey]).To(Equal("not ours"))
+	})
+})
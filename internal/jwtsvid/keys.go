@@ -0,0 +1,280 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jwtsvid issues JWT-SVIDs for the SPIFFE IDs of IdentityClaims to the
+// pods they select. Tokens are signed with keys the manager keeps in a Secret
+// and rotates, and published as a JSON Web Key Set for verifiers.
+package jwtsvid
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=create;update
+
+const (
+	// DefaultRotationPeriod is how long a key signs by default.
+	DefaultRotationPeriod = 24 * time.Hour
+	// PrePublishDelay is how long a new key is published before it signs,
+	// so verifiers and replicas that refreshed the JWKS meanwhile know it.
+	// The rotation period must cover it and the token lifetime.
+	PrePublishDelay = 2 * refreshInterval
+	// keysKey is the key of the Secret's data holding the signing keys.
+	keysKey = "keys.json"
+	// retainedKeys is the number of keys kept: the current and the previous.
+	retainedKeys = 2
+	// refreshInterval is how long keys read from the Secret are used before
+	// reading it again, which bounds how long replicas sign with a replaced key.
+	refreshInterval = 30 * time.Second
+	// rotationCheckInterval is how often the leader checks whether the
+	// current key is due for rotation.
+	rotationCheckInterval = time.Minute
+)
+
+// Key is a signing key.
+type Key struct {
+	// ID is the key's JWK thumbprint, used as its key ID.
+	ID string
+	// CreatedAt is when the key was generated.
+	CreatedAt time.Time
+	// PrivateKey signs ES256 tokens.
+	PrivateKey *ecdsa.PrivateKey
+}
+
+// storedKey is a Key as kept in the Secret.
+type storedKey struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	// PrivateKey is PKCS #8 encoded.
+	PrivateKey []byte `json:"privateKey"`
+}
+
+// KeyStore reads and rotates the signing keys kept in a Secret. A new key is
+// published PrePublishDelay before it signs, and the key it replaces keeps
+// signing meanwhile, then stays published until it is dropped at the next
+// rotation, so tokens it signed can be verified until they expire.
+type KeyStore struct {
+	// Client writes the Secret.
+	Client client.Client
+	// Reader reads the Secret, which the manager doesn't cache.
+	Reader client.Reader
+	// Secret is the Secret holding the keys.
+	Secret types.NamespacedName
+	// RotationPeriod is how long a key signs before it is replaced,
+	// DefaultRotationPeriod if zero.
+	RotationPeriod time.Duration
+
+	mu     sync.Mutex
+	keys   []Key
+	readAt time.Time
+}
+
+// Keys returns the signing keys, newest first, or none before the leader
+// created the first one.
+func (s *KeyStore) Keys(ctx context.Context) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.readAt.IsZero() && time.Since(s.readAt) < refreshInterval {
+		return s.keys, nil
+	}
+	keys, _, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.keys, s.readAt = keys, time.Now()
+	return keys, nil
+}
+
+// signingKey returns the key that signs at now: the newest one once it has
+// been published for PrePublishDelay, or the previous one until then. A first
+// key signs right away, since no token can be verified before it anyway.
+func signingKey(keys []Key, now time.Time) Key {
+	if len(keys) > 1 && now.Sub(keys[0].CreatedAt) < PrePublishDelay {
+		return keys[1]
+	}
+	return keys[0]
+}
+
+// read returns the keys in the Secret and the Secret, which is nil if it
+// doesn't exist.
+func (s *KeyStore) read(ctx context.Context) ([]Key, *corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := s.Reader.Get(ctx, s.Secret, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get Secret %s: %w", s.Secret, err)
+	}
+	data, ok := secret.Data[keysKey]
+	if !ok {
+		return nil, secret, nil
+	}
+	var stored []storedKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s in Secret %s: %w", keysKey, s.Secret, err)
+	}
+	keys := make([]Key, 0, len(stored))
+	for _, sk := range stored {
+		parsed, err := x509.ParsePKCS8PrivateKey(sk.PrivateKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid key %s in Secret %s: %w", sk.ID, s.Secret, err)
+		}
+		privateKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("key %s in Secret %s is not an ECDSA key", sk.ID, s.Secret)
+		}
+		keys = append(keys, Key{ID: sk.ID, CreatedAt: sk.CreatedAt, PrivateKey: privateKey})
+	}
+	return keys, secret, nil
+}
+
+// Start implements manager.Runnable. It rotates the keys until ctx is done.
+func (s *KeyStore) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx)
+
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.rotate(ctx); err != nil {
+			log.Error(err, "Failed to rotate JWT-SVID signing key")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the
+// leader rotates keys.
+func (s *KeyStore) NeedLeaderElection() bool {
+	return true
+}
+
+// rotate generates a new key when there is none or the newest one is due,
+// keeping the previous key and dropping older ones.
+func (s *KeyStore) rotate(ctx context.Context) error {
+	keys, secret, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	period := s.RotationPeriod
+	if period == 0 {
+		period = DefaultRotationPeriod
+	}
+	if len(keys) > 0 && time.Since(keys[0].CreatedAt) < period {
+		return nil
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return err
+	}
+	keys = append([]Key{key}, keys...)
+	keys = keys[:min(len(keys), retainedKeys)]
+	data, err := encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	if secret == nil {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: s.Secret.Name, Namespace: s.Secret.Namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{keysKey: data},
+		}
+		err = s.Client.Create(ctx, secret)
+	} else {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[keysKey] = data
+		err = s.Client.Update(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store signing keys in Secret %s: %w", s.Secret, err)
+	}
+	logf.FromContext(ctx).Info("Rotated JWT-SVID signing key", "kid", key.ID)
+
+	s.mu.Lock()
+	s.keys, s.readAt = keys, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// newKey generates a P-256 signing key.
+func newKey() (Key, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+	jwk := jose.JSONWebKey{Key: &privateKey.PublicKey}
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{
+		ID:         base64.RawURLEncoding.EncodeToString(thumbprint),
+		CreatedAt:  time.Now(),
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// encodeKeys encodes keys for the Secret.
+func encodeKeys(keys []Key) ([]byte, error) {
+	stored := make([]storedKey, 0, len(keys))
+	for _, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, storedKey{ID: key.ID, CreatedAt: key.CreatedAt, PrivateKey: der})
+	}
+	return json.Marshal(stored)
+}
+
+// keySet returns the public keys as a JSON Web Key Set.
+func keySet(keys []Key) jose.JSONWebKeySet {
+	set := jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key:       &key.PrivateKey.PublicKey,
+			KeyID:     key.ID,
+			Algorithm: string(jose.ES256),
+			Use:       "sig",
+		})
+	}
+	return set
+}
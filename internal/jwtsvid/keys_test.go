@@ -0,0 +1,106 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwtsvid
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var keySecret = types.NamespacedName{Namespace: "identity-system", Name: "jwt-svid-keys"}
+
+// newKeyStore returns a KeyStore holding its Secret in c.
+func newKeyStore(c client.Client) *KeyStore {
+	return &KeyStore{Client: c, Reader: c, Secret: keySecret, RotationPeriod: time.Hour}
+}
+
+var _ = Describe("KeyStore", func() {
+	var (
+		ctx   context.Context
+		c     client.Client
+		store *KeyStore
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		store = newKeyStore(c)
+	})
+
+	It("should create the first key in the Secret", func() {
+		Expect(store.rotate(ctx)).To(Succeed())
+
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, keySecret, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKey(keysKey))
+
+		// Other replicas read it from the Secret
+		keys, err := newKeyStore(c).Keys(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(HaveLen(1))
+		Expect(keys[0].ID).NotTo(BeEmpty())
+		Expect(keys[0].PrivateKey).NotTo(BeNil())
+
+		// The key isn't due yet
+		Expect(store.rotate(ctx)).To(Succeed())
+		again, _, err := store.read(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(HaveLen(1))
+		Expect(again[0].ID).To(Equal(keys[0].ID))
+	})
+
+	It("should keep the previous key and drop older ones", func() {
+		store.RotationPeriod = time.Nanosecond
+		var ids []string
+		for range 3 {
+			Expect(store.rotate(ctx)).To(Succeed())
+			keys, err := store.Keys(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			ids = append(ids, keys[0].ID)
+		}
+
+		keys, _, err := store.read(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(HaveLen(2))
+		Expect(keys[0].ID).To(Equal(ids[2]))
+		Expect(keys[1].ID).To(Equal(ids[1]))
+		Expect(keySet(keys).Keys).To(HaveEach(HaveField("Use", "sig")))
+	})
+
+	It("should sign with the previous key until a new one has been published", func() {
+		store.RotationPeriod = time.Nanosecond
+		Expect(store.rotate(ctx)).To(Succeed())
+		Expect(store.rotate(ctx)).To(Succeed())
+		keys, err := store.Keys(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(HaveLen(2))
+
+		now := keys[0].CreatedAt
+		Expect(signingKey(keys, now).ID).To(Equal(keys[1].ID))
+		Expect(signingKey(keys, now.Add(PrePublishDelay)).ID).To(Equal(keys[0].ID))
+		// A first key signs right away
+		Expect(signingKey(keys[:1], now).ID).To(Equal(keys[0].ID))
+	})
+})
@@ -0,0 +1,305 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwtsvid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+
+// Endpoints of the Issuer.
+const (
+	// TokenPath issues JWT-SVIDs.
+	TokenPath = "/v1/token"
+	// KeysPath publishes the signing keys as a JSON Web Key Set.
+	KeysPath = "/keys"
+)
+
+const (
+	// DefaultAudience is the audience ServiceAccount tokens must be bound to
+	// by default.
+	DefaultAudience = "identity.cluster.local"
+	// DefaultTTL is the lifetime of JWT-SVIDs by default.
+	DefaultTTL = 5 * time.Minute
+	// maxRequestSize limits the size of token request bodies.
+	maxRequestSize = 64 << 10
+)
+
+// Extra user info of ServiceAccount tokens bound to a pod.
+const (
+	podNameExtra = "authentication.kubernetes.io/pod-name"
+	podUIDExtra  = "authentication.kubernetes.io/pod-uid"
+)
+
+// serviceAccountPrefix prefixes the usernames of ServiceAccounts.
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// TokenRequest is the body of a request to TokenPath.
+type TokenRequest struct {
+	// Audience lists the audiences of the JWT-SVID; at least one is required.
+	Audience []string `json:"audience"`
+	// Claim names the IdentityClaim whose SPIFFE ID is issued. The oldest
+	// Ready claim selecting the pod is used otherwise.
+	Claim string `json:"claim,omitempty"`
+}
+
+// TokenResponse is the response to a TokenRequest.
+type TokenResponse struct {
+	// Token is the JWT-SVID.
+	Token string `json:"token"`
+	// SpiffeID is the subject of the token.
+	SpiffeID string `json:"spiffeID"`
+	// ExpiresAt is when the token expires.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// requestError is a failed request, reported with its HTTP status.
+type requestError struct {
+	code    int
+	message string
+}
+
+// Error implements error
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// fail returns a requestError.
+func fail(code int, format string, args ...any) error {
+	return &requestError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Issuer serves the token and key endpoints. Pods authenticate with a
+// projected ServiceAccount token bound to them, which is validated with a
+// TokenReview, and get JWT-SVIDs for the IdentityClaims selecting them.
+type Issuer struct {
+	// Client reads Pods and IdentityClaims and creates TokenReviews.
+	Client client.Client
+	// Keys holds the signing keys.
+	Keys *KeyStore
+	// Audience is the audience ServiceAccount tokens must be bound to, so
+	// tokens meant for other services can't be replayed. DefaultAudience if
+	// empty.
+	Audience string
+	// TTL is the lifetime of JWT-SVIDs, DefaultTTL if zero.
+	TTL time.Duration
+}
+
+// Handler returns the handler of the endpoints.
+func (i *Issuer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+TokenPath, i.serveToken)
+	mux.HandleFunc("GET "+KeysPath, i.serveKeys)
+	return mux
+}
+
+// serveToken issues a JWT-SVID to the pod of the bearer token.
+func (i *Issuer) serveToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logf.FromContext(ctx)
+
+	resp, err := i.issue(ctx, r)
+	if err != nil {
+		var reqErr *requestError
+		if !errors.As(err, &reqErr) {
+			log.Error(err, "Failed to issue JWT-SVID")
+			reqErr = &requestError{code: http.StatusInternalServerError, message: "internal error"}
+		}
+		writeJSON(w, reqErr.code, map[string]string{"error": reqErr.message})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// issue handles a token request.
+func (i *Issuer) issue(ctx context.Context, r *http.Request) (*TokenResponse, error) {
+	log := logf.FromContext(ctx)
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, fail(http.StatusUnauthorized, "a ServiceAccount token is required")
+	}
+	req := &TokenRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestSize)).Decode(req); err != nil {
+		return nil, fail(http.StatusBadRequest, "invalid request: %v", err)
+	}
+	if len(req.Audience) == 0 || slices.Contains(req.Audience, "") {
+		return nil, fail(http.StatusBadRequest, "audience is required")
+	}
+
+	pod, err := i.authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	claim, err := i.claim(ctx, pod, req.Claim)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := i.sign(ctx, claim.Status.SpiffeID, req.Audience)
+	if err != nil {
+		return nil, err
+	}
+	log.V(1).Info("Issued JWT-SVID", "pod", client.ObjectKeyFromObject(pod), "claim", claim.Name,
+		"spiffeID", resp.SpiffeID, "audience", req.Audience)
+	return resp, nil
+}
+
+// authenticate returns the pod a ServiceAccount token is bound to.
+func (i *Issuer) authenticate(ctx context.Context, token string) (*corev1.Pod, error) {
+	audience := i.Audience
+	if audience == "" {
+		audience = DefaultAudience
+	}
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: []string{audience}},
+	}
+	if err := i.Client.Create(ctx, review); err != nil {
+		return nil, fail(http.StatusServiceUnavailable, "failed to review token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, fail(http.StatusUnauthorized, "invalid token: %s", review.Status.Error)
+	}
+
+	user := review.Status.User
+	namespace, serviceAccount, ok := strings.Cut(strings.TrimPrefix(user.Username, serviceAccountPrefix), ":")
+	if !strings.HasPrefix(user.Username, serviceAccountPrefix) || !ok {
+		return nil, fail(http.StatusUnauthorized, "%s is not a ServiceAccount", user.Username)
+	}
+	podName, podUID := user.Extra[podNameExtra], user.Extra[podUIDExtra]
+	if len(podName) != 1 || len(podUID) != 1 {
+		return nil, fail(http.StatusUnauthorized, "token is not bound to a pod")
+	}
+
+	pod := &corev1.Pod{}
+	if err := i.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: podName[0]}, pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fail(http.StatusUnauthorized, "pod %s/%s no longer exists", namespace, podName[0])
+		}
+		return nil, fail(http.StatusServiceUnavailable, "failed to get pod: %v", err)
+	}
+	if string(pod.UID) != podUID[0] || pod.Spec.ServiceAccountName != serviceAccount {
+		return nil, fail(http.StatusUnauthorized, "pod %s/%s no longer exists", namespace, podName[0])
+	}
+	return pod, nil
+}
+
+// claim returns the IdentityClaim to issue for: the named one, or the oldest
+// Ready claim selecting the pod.
+func (i *Issuer) claim(ctx context.Context, pod *corev1.Pod, name string) (*identityv1alpha1.IdentityClaim, error) {
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := i.Client.List(ctx, claims, client.InNamespace(pod.Namespace)); err != nil {
+		return nil, fail(http.StatusServiceUnavailable, "failed to list IdentityClaims: %v", err)
+	}
+	slices.SortFunc(claims.Items, func(a, b identityv1alpha1.IdentityClaim) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for j := range claims.Items {
+		claim := &claims.Items[j]
+		if name != "" && claim.Name != name {
+			continue
+		}
+		if claim.Status.SpiffeID == "" || !claim.Servable() {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		return claim, nil
+	}
+	if name != "" {
+		return nil, fail(http.StatusForbidden, "IdentityClaim %s doesn't select pod %s/%s or isn't Ready", name, pod.Namespace, pod.Name)
+	}
+	return nil, fail(http.StatusForbidden, "no Ready IdentityClaim selects pod %s/%s", pod.Namespace, pod.Name)
+}
+
+// sign returns a JWT-SVID for spiffeID, signed with the current key.
+func (i *Issuer) sign(ctx context.Context, spiffeID string, audience []string) (*TokenResponse, error) {
+	keys, err := i.Keys.Keys(ctx)
+	if err != nil {
+		return nil, fail(http.StatusServiceUnavailable, "failed to read signing keys: %v", err)
+	}
+	if len(keys) == 0 {
+		return nil, fail(http.StatusServiceUnavailable, "no signing key has been created yet")
+	}
+	now := time.Now()
+	key := signingKey(keys, now)
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: key.PrivateKey, KeyID: key.ID}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := i.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Subject:  spiffeID,
+		Audience: jwt.Audience(audience),
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(expiresAt),
+	}).Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{Token: token, SpiffeID: spiffeID, ExpiresAt: expiresAt}, nil
+}
+
+// serveKeys publishes the signing keys, including a new one before it signs
+// and the previous one after.
+func (i *Issuer) serveKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := i.Keys.Keys(r.Context())
+	if err != nil {
+		logf.FromContext(r.Context()).Error(err, "Failed to read signing keys")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "failed to read signing keys"})
+		return
+	}
+	writeJSON(w, http.StatusOK, keySet(keys))
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
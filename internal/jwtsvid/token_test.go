@@ -0,0 +1,225 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwtsvid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spiffejwt "github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+const spiffeID = "spiffe://cluster.local/ns/default/ic/web"
+
+// reviewTokens authenticates TokenReviews like the API server: tokens maps
+// each valid token bound to the issuer's audience to its user.
+func reviewTokens(tokens map[string]authenticationv1.UserInfo) interceptor.Funcs {
+	return interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review, ok := obj.(*authenticationv1.TokenReview)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			user, ok := tokens[review.Spec.Token]
+			if !ok || len(review.Spec.Audiences) != 1 || review.Spec.Audiences[0] != DefaultAudience {
+				review.Status = authenticationv1.TokenReviewStatus{Error: "token not valid for audience"}
+				return nil
+			}
+			review.Status = authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User:          user,
+				Audiences:     review.Spec.Audiences,
+			}
+			return nil
+		},
+	}
+}
+
+// podUser is the user of a projected token of the web-0 pod.
+func podUser(name, uid string) authenticationv1.UserInfo {
+	return authenticationv1.UserInfo{
+		Username: "system:serviceaccount:default:web",
+		Extra: map[string]authenticationv1.ExtraValue{
+			podNameExtra: {name},
+			podUIDExtra:  {uid},
+		},
+	}
+}
+
+var _ = Describe("Issuer", func() {
+	var (
+		ctx    context.Context
+		c      client.Client
+		store  *KeyStore
+		server *httptest.Server
+	)
+
+	// requestToken requests a JWT-SVID with the ServiceAccount token.
+	requestToken := func(token string, req TokenRequest) (*http.Response, []byte) {
+		body, err := json.Marshal(req)
+		Expect(err).NotTo(HaveOccurred())
+		httpReq, err := http.NewRequest(http.MethodPost, server.URL+TokenPath, bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := server.Client().Do(httpReq)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = resp.Body.Close() }()
+		data, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp, data
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default", UID: "web-0-uid",
+						Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{ServiceAccountName: "web"},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "batch-0", Namespace: "default", UID: "batch-0-uid",
+						Labels: map[string]string{"app": "batch"}},
+					Spec: corev1.PodSpec{ServiceAccountName: "web"},
+				},
+				&identityv1alpha1.IdentityClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+					Spec: identityv1alpha1.IdentityClaimSpec{
+						Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+					},
+					Status: identityv1alpha1.IdentityClaimStatus{
+						SpiffeID: spiffeID,
+						Conditions: []metav1.Condition{{
+							Type:   identityv1alpha1.ConditionReady,
+							Status: metav1.ConditionTrue,
+							Reason: "Issued",
+						}},
+					},
+				}).
+			WithInterceptorFuncs(reviewTokens(map[string]authenticationv1.UserInfo{
+				"web-token":    podUser("web-0", "web-0-uid"),
+				"batch-token":  podUser("batch-0", "batch-0-uid"),
+				"stale-token":  podUser("web-0", "deleted-uid"),
+				"legacy-token": {Username: "system:serviceaccount:default:web"},
+			})).
+			Build()
+
+		store = newKeyStore(c)
+		Expect(store.rotate(ctx)).To(Succeed())
+		server = httptest.NewServer((&Issuer{Client: c, Keys: store}).Handler())
+		DeferCleanup(server.Close)
+	})
+
+	It("should issue JWT-SVIDs that verify against the published keys", func() {
+		resp, body := requestToken("web-token", TokenRequest{Audience: []string{"orders-api"}})
+		Expect(resp.StatusCode).To(Equal(http.StatusOK), string(body))
+		issued := &TokenResponse{}
+		Expect(json.Unmarshal(body, issued)).To(Succeed())
+		Expect(issued.SpiffeID).To(Equal(spiffeID))
+
+		keysResp, err := server.Client().Get(server.URL + KeysPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = keysResp.Body.Close() }()
+		jwks, err := io.ReadAll(keysResp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		bundle, err := jwtbundle.Parse(spiffeid.RequireTrustDomainFromString("cluster.local"), jwks)
+		Expect(err).NotTo(HaveOccurred())
+
+		svid, err := spiffejwt.ParseAndValidate(issued.Token, bundle, []string{"orders-api"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(svid.ID.String()).To(Equal(spiffeID))
+		Expect(svid.Expiry).To(BeTemporally("~", issued.ExpiresAt, 0))
+
+		_, err = spiffejwt.ParseAndValidate(issued.Token, bundle, []string{"billing-api"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep verifying tokens signed with the previous key", func() {
+		resp, body := requestToken("web-token", TokenRequest{Audience: []string{"orders-api"}})
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		issued := &TokenResponse{}
+		Expect(json.Unmarshal(body, issued)).To(Succeed())
+
+		store.RotationPeriod = 1
+		Expect(store.rotate(ctx)).To(Succeed())
+		keys, err := store.Keys(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(HaveLen(2))
+
+		bundle := jwtbundle.New(spiffeid.RequireTrustDomainFromString("cluster.local"))
+		for _, key := range keySet(keys).Keys {
+			Expect(bundle.AddJWTAuthority(key.KeyID, key.Key)).To(Succeed())
+		}
+		_, err = spiffejwt.ParseAndValidate(issued.Token, bundle, []string{"orders-api"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny suspended and failed claims that are still Ready", func() {
+		claim := &identityv1alpha1.IdentityClaim{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, claim)).To(Succeed())
+		claim.Spec.Suspend = true
+		Expect(c.Update(ctx, claim)).To(Succeed())
+		resp, body := requestToken("web-token", TokenRequest{Audience: []string{"orders-api"}})
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden), string(body))
+
+		claim.Spec.Suspend = false
+		claim.Status.Phase = identityv1alpha1.PhaseFailed
+		Expect(c.Update(ctx, claim)).To(Succeed())
+		resp, body = requestToken("web-token", TokenRequest{Audience: []string{"orders-api"}, Claim: "web"})
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden), string(body))
+	})
+
+	DescribeTable("should deny requests",
+		func(token string, req TokenRequest, code int) {
+			resp, body := requestToken(token, req)
+			Expect(resp.StatusCode).To(Equal(code), string(body))
+			Expect(body).To(ContainSubstring(`"error"`))
+		},
+		Entry("without a token", "", TokenRequest{Audience: []string{"orders-api"}}, http.StatusUnauthorized),
+		Entry("without an audience", "web-token", TokenRequest{}, http.StatusBadRequest),
+		Entry("with a token for another audience", "unknown-token",
+			TokenRequest{Audience: []string{"orders-api"}}, http.StatusUnauthorized),
+		Entry("with a token not bound to a pod", "legacy-token",
+			TokenRequest{Audience: []string{"orders-api"}}, http.StatusUnauthorized),
+		Entry("with a token of a deleted pod", "stale-token",
+			TokenRequest{Audience: []string{"orders-api"}}, http.StatusUnauthorized),
+		Entry("from a pod no claim selects", "batch-token",
+			TokenRequest{Audience: []string{"orders-api"}}, http.StatusForbidden),
+		Entry("for a claim not selecting the pod", "web-token",
+			TokenRequest{Audience: []string{"orders-api"}, Claim: "batch"}, http.StatusForbidden),
+	)
+})
@@ -0,0 +1,215 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates IdentityClaims against the IdentityPolicies of their
+// namespace. It is shared by the admission webhook and the reconciler.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+const (
+	defaultIssuerKind  = "ClusterIssuer"
+	defaultIssuerGroup = "cert-manager.io"
+)
+
+// Request holds what a claim asks for. Empty fields are not known yet and
+// are not checked, so the webhook can evaluate what the spec states and the
+// reconciler can evaluate the defaulted and rendered values.
+type Request struct {
+	// Issuer is the issuer the claim references.
+	Issuer *identityv1alpha1.IssuerReference
+	// TTL is the requested certificate lifetime.
+	TTL time.Duration
+	// SpiffeIDPath is the SPIFFE ID path, with or without the leading slash.
+	SpiffeIDPath string
+	// KeyAlgorithm is the private key algorithm.
+	KeyAlgorithm identityv1alpha1.PrivateKeyAlgorithm
+}
+
+// NewRequest returns the request stated by the claim's spec, with the ttl and
+// key algorithm defaulted. The issuer is nil when the claim uses the operator's
+// default issuer; the SPIFFE ID path is spec.spiffeIDPath as written.
+func NewRequest(claim *identityv1alpha1.IdentityClaim) Request {
+	req := Request{
+		Issuer:       claim.Spec.IssuerRef,
+		TTL:          claim.Spec.TTL.Duration,
+		SpiffeIDPath: claim.Spec.SpiffeIDPath,
+		KeyAlgorithm: identityv1alpha1.KeyAlgorithmECDSA,
+	}
+	if req.TTL == 0 {
+		req.TTL = time.Hour
+	}
+	if claim.Spec.PrivateKey != nil && claim.Spec.PrivateKey.Algorithm != "" {
+		req.KeyAlgorithm = claim.Spec.PrivateKey.Algorithm
+	}
+	return req
+}
+
+// Violation describes why a policy denies a request.
+type Violation struct {
+	// Policy is the name of the denying IdentityPolicy.
+	Policy string
+	// Field is the path of the denied IdentityClaim field.
+	Field *field.Path
+	// Detail explains the denial.
+	Detail string
+}
+
+// Error implements error
+func (v *Violation) Error() string {
+	return fmt.Sprintf("denied by IdentityPolicy %s: %s: %s", v.Policy, v.Field, v.Detail)
+}
+
+// Evaluate checks req against every IdentityPolicy selecting namespace, in
+// name order, and returns the first violation or nil when all allow it.
+func Evaluate(ctx context.Context, c client.Reader, namespace string, req Request) (*Violation, error) {
+	policies := &identityv1alpha1.IdentityPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list IdentityPolicies: %w", err)
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	slices.SortFunc(policies.Items, func(a, b identityv1alpha1.IdentityPolicy) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for i := range policies.Items {
+		p := &policies.Items[i]
+		selector, err := metav1.LabelSelectorAsSelector(&p.Spec.NamespaceSelector)
+		if err != nil {
+			// A broken policy must not silently allow everything
+			return &Violation{Policy: p.Name, Field: field.NewPath("metadata", "namespace"),
+				Detail: fmt.Sprintf("policy has an invalid namespaceSelector: %v", err)}, nil
+		}
+		if !selector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		if v := Check(p, req); v != nil {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// Quota returns the lowest limits of the IdentityPolicies selecting ns.
+// Limits no policy sets are nil.
+func Quota(ctx context.Context, c client.Reader, ns *corev1.Namespace) (identityv1alpha1.IdentityQuota, error) {
+	var quota identityv1alpha1.IdentityQuota
+
+	policies := &identityv1alpha1.IdentityPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return quota, fmt.Errorf("failed to list IdentityPolicies: %w", err)
+	}
+	for i := range policies.Items {
+		p := &policies.Items[i]
+		if p.Spec.Quota == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&p.Spec.NamespaceSelector)
+		if err != nil || !selector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		quota.MaxClaims = lowest(quota.MaxClaims, p.Spec.Quota.MaxClaims)
+		quota.MaxIssuancesPerHour = lowest(quota.MaxIssuancesPerHour, p.Spec.Quota.MaxIssuancesPerHour)
+	}
+	return quota, nil
+}
+
+// lowest returns the lower of two optional limits.
+func lowest(a, b *int32) *int32 {
+	if a == nil || (b != nil && *b < *a) {
+		return b
+	}
+	return a
+}
+
+// Check returns the first constraint of p that req violates, or nil.
+func Check(p *identityv1alpha1.IdentityPolicy, req Request) *Violation {
+	spec := field.NewPath("spec")
+	deny := func(fld *field.Path, format string, args ...any) *Violation {
+		return &Violation{Policy: p.Name, Field: fld, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	if req.Issuer != nil && len(p.Spec.AllowedIssuers) > 0 {
+		issuer := normalizeIssuer(*req.Issuer)
+		allowed := slices.ContainsFunc(p.Spec.AllowedIssuers, func(ref identityv1alpha1.IssuerReference) bool {
+			return normalizeIssuer(ref) == issuer
+		})
+		if !allowed {
+			return deny(spec.Child("issuerRef"), "issuer %s %s (%s) is not allowed", issuer.Kind, issuer.Name, issuer.Group)
+		}
+	}
+
+	if req.TTL != 0 {
+		if p.Spec.MinTTL != nil && req.TTL < p.Spec.MinTTL.Duration {
+			return deny(spec.Child("ttl"), "ttl %s is shorter than the minimum of %s", req.TTL, p.Spec.MinTTL.Duration)
+		}
+		if p.Spec.MaxTTL != nil && req.TTL > p.Spec.MaxTTL.Duration {
+			return deny(spec.Child("ttl"), "ttl %s is longer than the maximum of %s", req.TTL, p.Spec.MaxTTL.Duration)
+		}
+	}
+
+	if req.SpiffeIDPath != "" && len(p.Spec.AllowedSpiffeIDPaths) > 0 {
+		spiffePath := strings.TrimPrefix(req.SpiffeIDPath, "/")
+		allowed := slices.ContainsFunc(p.Spec.AllowedSpiffeIDPaths, func(pattern string) bool {
+			matched, err := path.Match(strings.TrimPrefix(pattern, "/"), spiffePath)
+			return err == nil && matched
+		})
+		if !allowed {
+			return deny(spec.Child("spiffeIDPath"), "SPIFFE ID path %s does not match any of %s",
+				spiffePath, strings.Join(p.Spec.AllowedSpiffeIDPaths, ", "))
+		}
+	}
+
+	if req.KeyAlgorithm != "" && len(p.Spec.AllowedKeyAlgorithms) > 0 &&
+		!slices.Contains(p.Spec.AllowedKeyAlgorithms, req.KeyAlgorithm) {
+		return deny(spec.Child("privateKey", "algorithm"), "key algorithm %s is not allowed", req.KeyAlgorithm)
+	}
+
+	return nil
+}
+
+// normalizeIssuer fills in the defaults of the IssuerReference CRD schema.
+func normalizeIssuer(ref identityv1alpha1.IssuerReference) identityv1alpha1.IssuerReference {
+	if ref.Kind == "" {
+		ref.Kind = defaultIssuerKind
+	}
+	if ref.Group == "" {
+		ref.Group = defaultIssuerGroup
+	}
+	return ref
+}
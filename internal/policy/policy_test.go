@@ -0,0 +1,173 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+var _ = Describe("Policy", func() {
+	p := &identityv1alpha1.IdentityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-policy"},
+		Spec: identityv1alpha1.IdentityPolicySpec{
+			AllowedIssuers: []identityv1alpha1.IssuerReference{
+				{Name: "team-issuer"},
+				{Name: "ns-issuer", Kind: "Issuer"},
+			},
+			MinTTL:               &metav1.Duration{Duration: 10 * time.Minute},
+			MaxTTL:               &metav1.Duration{Duration: 24 * time.Hour},
+			AllowedSpiffeIDPaths: []string{"ns/team-a/*", "/shared/*/agent"},
+			AllowedKeyAlgorithms: []identityv1alpha1.PrivateKeyAlgorithm{identityv1alpha1.KeyAlgorithmECDSA},
+		},
+	}
+	allowed := Request{
+		Issuer:       &identityv1alpha1.IssuerReference{Name: "team-issuer", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+		TTL:          time.Hour,
+		SpiffeIDPath: "/ns/team-a/api",
+		KeyAlgorithm: identityv1alpha1.KeyAlgorithmECDSA,
+	}
+
+	It("allows requests within every constraint", func() {
+		Expect(Check(p, allowed)).To(BeNil())
+
+		req := allowed
+		req.Issuer = &identityv1alpha1.IssuerReference{Name: "ns-issuer", Kind: "Issuer"}
+		req.SpiffeIDPath = "shared/logging/agent"
+		Expect(Check(p, req)).To(BeNil())
+	})
+
+	It("skips values that are not known yet", func() {
+		Expect(Check(p, Request{})).To(BeNil())
+	})
+
+	DescribeTable("denies requests outside a constraint",
+		func(mutate func(*Request), fieldPath string) {
+			req := allowed
+			mutate(&req)
+			violation := Check(p, req)
+			Expect(violation).NotTo(BeNil())
+			Expect(violation.Policy).To(Equal("team-policy"))
+			Expect(violation.Field.String()).To(Equal(fieldPath))
+		},
+		Entry("issuer", func(r *Request) {
+			r.Issuer = &identityv1alpha1.IssuerReference{Name: "production-root"}
+		}, "spec.issuerRef"),
+		Entry("issuer kind", func(r *Request) {
+			r.Issuer = &identityv1alpha1.IssuerReference{Name: "team-issuer", Kind: "Issuer"}
+		}, "spec.issuerRef"),
+		Entry("short ttl", func(r *Request) { r.TTL = 5 * time.Minute }, "spec.ttl"),
+		Entry("long ttl", func(r *Request) { r.TTL = 48 * time.Hour }, "spec.ttl"),
+		Entry("SPIFFE ID path", func(r *Request) { r.SpiffeIDPath = "/ns/team-b/api" }, "spec.spiffeIDPath"),
+		Entry("nested SPIFFE ID path", func(r *Request) { r.SpiffeIDPath = "/ns/team-a/api/v2" }, "spec.spiffeIDPath"),
+		Entry("key algorithm", func(r *Request) { r.KeyAlgorithm = identityv1alpha1.KeyAlgorithmRSA }, "spec.privateKey.algorithm"),
+	)
+
+	It("defaults the request from the claim's spec", func() {
+		claim := &identityv1alpha1.IdentityClaim{}
+		req := NewRequest(claim)
+		Expect(req.TTL).To(Equal(time.Hour))
+		Expect(req.KeyAlgorithm).To(Equal(identityv1alpha1.KeyAlgorithmECDSA))
+		Expect(req.Issuer).To(BeNil())
+
+		claim.Spec.PrivateKey = &identityv1alpha1.PrivateKey{Algorithm: identityv1alpha1.KeyAlgorithmEd25519}
+		Expect(NewRequest(claim).KeyAlgorithm).To(Equal(identityv1alpha1.KeyAlgorithmEd25519))
+	})
+
+	It("evaluates only the policies selecting the namespace, in name order", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(identityv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		newPolicy := func(name, tenant string, maxTTL time.Duration) *identityv1alpha1.IdentityPolicy {
+			return &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: identityv1alpha1.IdentityPolicySpec{
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": tenant}},
+					MaxTTL:            &metav1.Duration{Duration: maxTTL},
+				},
+			}
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "a"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"tenant": "b"}}},
+			newPolicy("b-strict", "a", time.Hour),
+			newPolicy("a-lenient", "a", 4*time.Hour),
+			newPolicy("other-tenant", "b", time.Minute*10),
+		).Build()
+
+		ctx := context.Background()
+		violation, err := Evaluate(ctx, c, "team-a", Request{TTL: 2 * time.Hour})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation).NotTo(BeNil())
+		Expect(violation.Policy).To(Equal("b-strict"))
+
+		violation, err = Evaluate(ctx, c, "team-a", Request{TTL: 8 * time.Hour})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation.Policy).To(Equal("a-lenient"))
+
+		violation, err = Evaluate(ctx, c, "team-a", Request{TTL: 30 * time.Minute})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violation).To(BeNil())
+	})
+
+	It("returns the lowest quota of the policies selecting the namespace", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(identityv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		newPolicy := func(name, tenant string, quota *identityv1alpha1.IdentityQuota) *identityv1alpha1.IdentityPolicy {
+			return &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: identityv1alpha1.IdentityPolicySpec{
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": tenant}},
+					Quota:             quota,
+				},
+			}
+		}
+		limit := func(n int32) *int32 { return &n }
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newPolicy("claims", "a", &identityv1alpha1.IdentityQuota{MaxClaims: limit(10), MaxIssuancesPerHour: limit(100)}),
+			newPolicy("issuances", "a", &identityv1alpha1.IdentityQuota{MaxIssuancesPerHour: limit(20)}),
+			newPolicy("no-quota", "a", nil),
+			newPolicy("other-tenant", "b", &identityv1alpha1.IdentityQuota{MaxClaims: limit(1)}),
+		).Build()
+
+		ctx := context.Background()
+		quota, err := Quota(ctx, c, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "a"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(quota.MaxClaims).To(HaveValue(Equal(int32(10))))
+		Expect(quota.MaxIssuancesPerHour).To(HaveValue(Equal(int32(20))))
+
+		quota, err = Quota(ctx, c, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(quota.MaxClaims).To(BeNil())
+		Expect(quota.MaxIssuancesPerHour).To(BeNil())
+	})
+})
@@ -0,0 +1,58 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package spiffeid validates the components of SPIFFE IDs issued by the operator.
+package spiffeid
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultTrustDomain is used when no trust domain is configured.
+const DefaultTrustDomain = "cluster.local"
+
+// maxTrustDomainLength is the maximum length of a trust domain name.
+const maxTrustDomainLength = 255
+
+// ValidateTrustDomain checks td against the SPIFFE trust domain grammar:
+// lowercase letters, digits, dots, dashes and underscores only.
+// See https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE-ID.md#21-trust-domain
+func ValidateTrustDomain(td string) error {
+	if td == "" {
+		return errors.New("trust domain is empty")
+	}
+	if len(td) > maxTrustDomainLength {
+		return fmt.Errorf("trust domain is longer than %d characters", maxTrustDomainLength)
+	}
+	for i := 0; i < len(td); i++ {
+		if !isTrustDomainChar(td[i]) {
+			return fmt.Errorf("trust domain %q contains invalid character %q: "+
+				"only lowercase letters, digits, '.', '-' and '_' are allowed", td, td[i])
+		}
+	}
+	return nil
+}
+
+func isTrustDomainChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		return true
+	case c == '.', c == '-', c == '_':
+		return true
+	}
+	return false
+}
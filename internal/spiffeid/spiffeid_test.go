@@ -0,0 +1,47 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffeid
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateTrustDomain", func() {
+	DescribeTable("accepts valid trust domains",
+		func(td string) {
+			Expect(ValidateTrustDomain(td)).To(Succeed())
+		},
+		Entry("default", DefaultTrustDomain),
+		Entry("multi-label", "prod.example.org"),
+		Entry("dash and underscore", "prod-eu_1.example"),
+	)
+
+	DescribeTable("rejects invalid trust domains",
+		func(td string) {
+			Expect(ValidateTrustDomain(td)).NotTo(Succeed())
+		},
+		Entry("empty", ""),
+		Entry("uppercase", "Cluster.Local"),
+		Entry("port", "example.org:8080"),
+		Entry("scheme", "spiffe://example.org"),
+		Entry("path", "example.org/ns"),
+		Entry("too long", strings.Repeat("a", 256)),
+	)
+})
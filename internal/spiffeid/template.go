@@ -0,0 +1,104 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffeid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultPathTemplate renders the operator's historical ns/<namespace>/ic/<name> path.
+const DefaultPathTemplate = "ns/{{.Namespace}}/ic/{{.Name}}"
+
+// TemplateData holds the values available to SPIFFE ID path templates.
+type TemplateData struct {
+	// Namespace of the IdentityClaim.
+	Namespace string
+	// Name of the IdentityClaim.
+	Name string
+	// ServiceAccount shared by every pod selected by the claim.
+	ServiceAccount string
+	// Labels carried with the same value by every pod selected by the claim.
+	Labels map[string]string
+}
+
+// Template renders SPIFFE ID paths such as ns/{{.Namespace}}/sa/{{.ServiceAccount}}.
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate parses a SPIFFE ID path template.
+func ParseTemplate(text string) (*Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("SPIFFE ID path template is empty")
+	}
+	tmpl, err := template.New("spiffeIDPath").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE ID path template: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Render executes the template and returns the validated path with a leading slash.
+func (t *Template) Render(data TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render SPIFFE ID path: %w", err)
+	}
+	path := "/" + strings.TrimPrefix(buf.String(), "/")
+	if err := ValidatePath(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ValidatePath checks path against the SPIFFE path grammar: non-empty segments
+// of letters, digits, '.', '-' and '_', excluding the relative segments "." and "..".
+// See https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE-ID.md#22-path
+func ValidatePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("SPIFFE ID path %q must start with '/'", path)
+	}
+	for _, segment := range strings.Split(path[1:], "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("SPIFFE ID path %q contains an empty segment", path)
+		case ".", "..":
+			return fmt.Errorf("SPIFFE ID path %q contains a relative segment", path)
+		}
+		for i := 0; i < len(segment); i++ {
+			if !isPathChar(segment[i]) {
+				return fmt.Errorf("SPIFFE ID path %q contains invalid character %q: "+
+					"only letters, digits, '.', '-' and '_' are allowed", path, segment[i])
+			}
+		}
+	}
+	return nil
+}
+
+func isPathChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	case c == '.', c == '-', c == '_':
+		return true
+	}
+	return false
+}
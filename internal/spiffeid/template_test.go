@@ -0,0 +1,65 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spiffeid
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	data := TemplateData{
+		Namespace:      "payments",
+		Name:           "api",
+		ServiceAccount: "api-sa",
+		Labels:         map[string]string{"app": "api", "app.kubernetes.io/version": "v2"},
+	}
+
+	DescribeTable("renders valid paths",
+		func(text, expected string) {
+			tmpl, err := ParseTemplate(text)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tmpl.Render(data)).To(Equal(expected))
+		},
+		Entry("default", DefaultPathTemplate, "/ns/payments/ic/api"),
+		Entry("istio convention", "ns/{{.Namespace}}/sa/{{.ServiceAccount}}", "/ns/payments/sa/api-sa"),
+		Entry("leading slash", "/workload/{{.Labels.app}}", "/workload/api"),
+		Entry("dotted label", `app/{{index .Labels "app.kubernetes.io/version"}}`, "/app/v2"),
+	)
+
+	DescribeTable("rejects templates that render illegal paths",
+		func(text string) {
+			tmpl, err := ParseTemplate(text)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = tmpl.Render(data)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing label", "app/{{.Labels.tier}}"),
+		Entry("unknown field", "{{.Cluster}}/{{.Name}}"),
+		Entry("empty segment", "ns//{{.Name}}"),
+		Entry("relative segment", "ns/../{{.Name}}"),
+		Entry("trailing slash", "ns/{{.Namespace}}/"),
+		Entry("invalid character", "ns/{{.Namespace}}/ic/{{.Name}}?x=1"),
+	)
+
+	It("rejects unparsable templates", func() {
+		_, err := ParseTemplate("ns/{{.Namespace")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseTemplate("")
+		Expect(err).To(HaveOccurred())
+	})
+})
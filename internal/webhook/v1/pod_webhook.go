@@ -0,0 +1,245 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+const (
+	// InjectLabel opts a namespace or pod into identity injection with the value
+	// "enabled". A pod can opt out of an enabled namespace with "disabled".
+	InjectLabel = "identity.cluster.local/inject"
+	// MountPathAnnotation overrides the directory the identity is mounted at.
+	MountPathAnnotation = "identity.cluster.local/mount-path"
+	// InjectedClaimAnnotation records which IdentityClaim was injected into the pod.
+	InjectedClaimAnnotation = "identity.cluster.local/injected-claim"
+
+	// DefaultMountPath is where the identity is mounted when not configured.
+	DefaultMountPath = "/var/run/secrets/identity"
+
+	injectEnabled  = "enabled"
+	injectDisabled = "disabled"
+
+	// volumeName is the name of the injected projected volume.
+	volumeName = "identity-claim"
+)
+
+// Environment variables set on every container of an injected pod.
+const (
+	EnvSpiffeID = "SPIFFE_ID"
+	EnvCertFile = "IDENTITY_CERT_FILE"
+	EnvKeyFile  = "IDENTITY_KEY_FILE"
+	EnvCAFile   = "IDENTITY_CA_FILE"
+)
+
+var podlog = logf.Log.WithName("pod-resource")
+
+// SetupPodWebhookWithManager registers the identity injection webhook for Pods
+// in the manager. Identities are mounted at mountPath unless a pod overrides it.
+func SetupPodWebhookWithManager(mgr ctrl.Manager, mountPath string) error {
+	return ctrl.NewWebhookManagedBy(mgr, &corev1.Pod{}).
+		WithDefaulter(&PodCustomDefaulter{Client: mgr.GetClient(), MountPath: mountPath}).
+		Complete()
+}
+
+// The webhook is registered twice, for pods labeled with InjectLabel and for
+// pods of namespaces labeled with it. config/webhook/pod_selector_patch.yaml
+// adds the disjoint selectors, so other pods never wait on the operator.
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1,timeoutSeconds=5
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-namespace-v1.kb.io,admissionReviewVersions=v1,timeoutSeconds=5
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// PodCustomDefaulter mounts the identity Secret of the IdentityClaim selecting
+// a pod into that pod, so workloads don't have to reference it by hand.
+type PodCustomDefaulter struct {
+	Client client.Reader
+	// MountPath is the default directory of the mounted identity.
+	// Defaults to DefaultMountPath when empty.
+	MountPath string
+}
+
+var _ admission.Defaulter[*corev1.Pod] = &PodCustomDefaulter{}
+
+// Default implements admission.Defaulter
+func (d *PodCustomDefaulter) Default(ctx context.Context, pod *corev1.Pod) error {
+	// Pods created through a controller don't have a namespace set yet.
+	namespace := pod.Namespace
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Namespace != "" {
+		namespace = req.Namespace
+	}
+
+	enabled, err := d.injectionEnabled(ctx, namespace, pod)
+	if err != nil || !enabled {
+		return err
+	}
+
+	claim, err := d.findClaim(ctx, namespace, pod)
+	if err != nil || claim == nil {
+		return err
+	}
+
+	mountPath := d.MountPath
+	if mountPath == "" {
+		mountPath = DefaultMountPath
+	}
+	if override, ok := pod.Annotations[MountPathAnnotation]; ok {
+		if !path.IsAbs(override) {
+			return fmt.Errorf("annotation %s must be an absolute path, got %q", MountPathAnnotation, override)
+		}
+		mountPath = override
+	}
+
+	podlog.V(1).Info("injecting identity", "namespace", namespace, "pod", pod.GenerateName+pod.Name,
+		"claim", claim.Name)
+	injectIdentity(pod, claim, path.Clean(mountPath))
+	return nil
+}
+
+// injectionEnabled reports whether the pod or its namespace opted into injection.
+func (d *PodCustomDefaulter) injectionEnabled(ctx context.Context, namespace string, pod *corev1.Pod) (bool, error) {
+	switch pod.Labels[InjectLabel] {
+	case injectEnabled:
+		return true, nil
+	case injectDisabled:
+		return false, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := d.Client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return ns.Labels[InjectLabel] == injectEnabled, nil
+}
+
+// findClaim returns the IdentityClaim whose selector matches the pod, or nil.
+// When several claims match, the oldest one wins so the choice is stable.
+func (d *PodCustomDefaulter) findClaim(ctx context.Context, namespace string, pod *corev1.Pod) (*identityv1alpha1.IdentityClaim, error) {
+	claims := &identityv1alpha1.IdentityClaimList{}
+	if err := d.Client.List(ctx, claims, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list IdentityClaims: %w", err)
+	}
+
+	podLabels := labels.Set(pod.Labels)
+	var matching []*identityv1alpha1.IdentityClaim
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(podLabels) {
+			continue
+		}
+		matching = append(matching, claim)
+	}
+	if len(matching) == 0 {
+		return nil, nil
+	}
+
+	slices.SortFunc(matching, func(a, b *identityv1alpha1.IdentityClaim) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return matching[0], nil
+}
+
+// injectIdentity adds a read-only projected volume holding the claim's
+// certificate, key and CA at mountPath to every container of the pod, along
+// with environment variables pointing at them. Pods that already have the
+// volume are left untouched.
+func injectIdentity(pod *corev1.Pod, claim *identityv1alpha1.IdentityClaim, mountPath string) {
+	if slices.ContainsFunc(pod.Spec.Volumes, func(v corev1.Volume) bool { return v.Name == volumeName }) {
+		return
+	}
+
+	secretName := claim.IdentitySecretName()
+	optional := true
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+						Items: []corev1.KeyToPath{
+							{Key: corev1.TLSCertKey, Path: corev1.TLSCertKey},
+							{Key: corev1.TLSPrivateKeyKey, Path: corev1.TLSPrivateKeyKey},
+						},
+					}},
+					// ca.crt is only present when the issuer returns a CA certificate.
+					{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+						Items:                []corev1.KeyToPath{{Key: cmmeta.TLSCAKey, Path: cmmeta.TLSCAKey}},
+						Optional:             &optional,
+					}},
+				},
+			},
+		},
+	})
+
+	env := []corev1.EnvVar{
+		{Name: EnvCertFile, Value: path.Join(mountPath, corev1.TLSCertKey)},
+		{Name: EnvKeyFile, Value: path.Join(mountPath, corev1.TLSPrivateKeyKey)},
+		{Name: EnvCAFile, Value: path.Join(mountPath, cmmeta.TLSCAKey)},
+	}
+	// The SPIFFE ID is only known once the claim has been reconciled.
+	if claim.Status.SpiffeID != "" {
+		env = append(env, corev1.EnvVar{Name: EnvSpiffeID, Value: claim.Status.SpiffeID})
+	}
+
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			container := &containers[i]
+			if slices.ContainsFunc(container.VolumeMounts, func(m corev1.VolumeMount) bool { return m.MountPath == mountPath }) {
+				continue
+			}
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: mountPath,
+				ReadOnly:  true,
+			})
+			for _, e := range env {
+				if !slices.ContainsFunc(container.Env, func(existing corev1.EnvVar) bool { return existing.Name == e.Name }) {
+					container.Env = append(container.Env, e)
+				}
+			}
+		}
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[InjectedClaimAnnotation] = claim.Name
+}
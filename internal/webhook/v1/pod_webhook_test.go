@@ -0,0 +1,166 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+var _ = Describe("Pod Webhook", func() {
+	const (
+		enabledNamespace = "identity-inject-enabled"
+		claimName        = "web-identity"
+		spiffeID         = "spiffe://cluster.local/ns/identity-inject-enabled/ic/web-identity"
+	)
+
+	newPod := func(name, namespace string, podLabels, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      podLabels,
+				Annotations: annotations,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "busybox"}},
+			},
+		}
+	}
+
+	createClaim := func(namespace string) {
+		claim := &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: namespace},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				TTL:      metav1.Duration{Duration: 1 * time.Hour},
+			},
+		}
+		Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+		claim.Status.SpiffeID = spiffeID
+		Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+
+		// The webhook reads claims through the manager's cache.
+		Eventually(func(g Gomega) {
+			cached := &identityv1alpha1.IdentityClaim{}
+			g.Expect(mgrClient.Get(ctx, types.NamespacedName{Name: claimName, Namespace: namespace}, cached)).To(Succeed())
+			g.Expect(cached.Status.SpiffeID).To(Equal(spiffeID))
+		}).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		ns := &corev1.Namespace{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: enabledNamespace}, ns); err != nil {
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   enabledNamespace,
+					Labels: map[string]string{InjectLabel: "enabled"},
+				},
+			})).To(Succeed())
+		}
+		createClaim(enabledNamespace)
+		createClaim("default")
+	})
+
+	AfterEach(func() {
+		for _, namespace := range []string{enabledNamespace, "default"} {
+			Expect(k8sClient.DeleteAllOf(ctx, &corev1.Pod{},
+				client.InNamespace(namespace), client.GracePeriodSeconds(0))).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &identityv1alpha1.IdentityClaim{}, client.InNamespace(namespace))).To(Succeed())
+		}
+	})
+
+	Context("When the namespace opts into injection", func() {
+		It("should mount the identity and set environment variables", func() {
+			pod := newPod("web", enabledNamespace, map[string]string{"app": "web"}, nil)
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+			Expect(pod.Annotations).To(HaveKeyWithValue(InjectedClaimAnnotation, claimName))
+			Expect(pod.Spec.Volumes).To(ContainElement(HaveField("Name", volumeName)))
+			volume := pod.Spec.Volumes[len(pod.Spec.Volumes)-1]
+			Expect(volume.Projected.Sources).To(HaveLen(2))
+			Expect(volume.Projected.Sources[0].Secret.Name).To(Equal("web-identity-identity"))
+
+			container := pod.Spec.Containers[0]
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name: volumeName, MountPath: DefaultMountPath, ReadOnly: true,
+			}))
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: EnvSpiffeID, Value: spiffeID},
+				corev1.EnvVar{Name: EnvCertFile, Value: DefaultMountPath + "/tls.crt"},
+				corev1.EnvVar{Name: EnvKeyFile, Value: DefaultMountPath + "/tls.key"},
+				corev1.EnvVar{Name: EnvCAFile, Value: DefaultMountPath + "/ca.crt"},
+			))
+		})
+
+		It("should leave pods that opt out or match no claim untouched", func() {
+			optedOut := newPod("web-opted-out", enabledNamespace,
+				map[string]string{"app": "web", InjectLabel: "disabled"}, nil)
+			Expect(k8sClient.Create(ctx, optedOut)).To(Succeed())
+			Expect(optedOut.Spec.Volumes).NotTo(ContainElement(HaveField("Name", volumeName)))
+
+			unmatched := newPod("api", enabledNamespace, map[string]string{"app": "api"}, nil)
+			Expect(k8sClient.Create(ctx, unmatched)).To(Succeed())
+			Expect(unmatched.Spec.Volumes).NotTo(ContainElement(HaveField("Name", volumeName)))
+		})
+	})
+
+	Context("When only the pod opts into injection", func() {
+		It("should honor the mount path annotation", func() {
+			pod := newPod("web", "default",
+				map[string]string{"app": "web", InjectLabel: "enabled"},
+				map[string]string{MountPathAnnotation: "/etc/identity"})
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+			Expect(pod.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name: volumeName, MountPath: "/etc/identity", ReadOnly: true,
+			}))
+			Expect(pod.Spec.Containers[0].Env).To(ContainElement(
+				corev1.EnvVar{Name: EnvCertFile, Value: "/etc/identity/tls.crt"}))
+		})
+
+		It("should not inject pods in namespaces that didn't opt in", func() {
+			pod := newPod("web-plain", "default", map[string]string{"app": "web"}, nil)
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			Expect(pod.Annotations).NotTo(HaveKey(InjectedClaimAnnotation))
+		})
+	})
+
+	Context("When injecting into a pod twice", func() {
+		It("should not duplicate the volume or override existing env vars", func() {
+			claim := &identityv1alpha1.IdentityClaim{ObjectMeta: metav1.ObjectMeta{Name: claimName}}
+			pod := newPod("web", "default", nil, nil)
+			pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: EnvCertFile, Value: "/custom/cert.pem"}}
+
+			injectIdentity(pod, claim, DefaultMountPath)
+			injectIdentity(pod, claim, DefaultMountPath)
+
+			Expect(pod.Spec.Volumes).To(HaveLen(1))
+			Expect(pod.Spec.Containers[0].VolumeMounts).To(HaveLen(1))
+			Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: EnvCertFile, Value: "/custom/cert.pem"}))
+			Expect(pod.Spec.Containers[0].Env).NotTo(ContainElement(HaveField("Name", EnvSpiffeID)))
+		})
+	})
+})
@@ -0,0 +1,197 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+	"github.com/osagberg/identity-claim-operator/internal/policy"
+)
+
+// certManagerGroup is the API group of cert-manager's built-in issuers.
+const certManagerGroup = "cert-manager.io"
+
+// certManagerIssuerKinds are the issuer kinds served by cert-manager itself.
+var certManagerIssuerKinds = []string{"Issuer", "ClusterIssuer"}
+
+var identityclaimlog = logf.Log.WithName("identityclaim-resource")
+
+// SetupIdentityClaimWebhookWithManager registers the webhook for IdentityClaim in the manager.
+// Issuers outside cert-manager.io are only accepted from allowedIssuerGroups.
+func SetupIdentityClaimWebhookWithManager(mgr ctrl.Manager, allowedIssuerGroups []string) error {
+	return ctrl.NewWebhookManagedBy(mgr, &identityv1alpha1.IdentityClaim{}).
+		WithValidator(&IdentityClaimCustomValidator{
+			Client:              mgr.GetClient(),
+			AllowedIssuerGroups: allowedIssuerGroups,
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-identity-cluster-local-v1alpha1-identityclaim,mutating=false,failurePolicy=fail,sideEffects=None,groups=identity.cluster.local,resources=identityclaims,verbs=create;update,versions=v1alpha1,name=videntityclaim-v1alpha1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:rbac:groups=identity.cluster.local,resources=identitypolicies,verbs=get;list;watch
+
+// IdentityClaimCustomValidator rejects IdentityClaims the controller could never
+// reconcile, so mistakes surface at apply time instead of as a Failed phase.
+type IdentityClaimCustomValidator struct {
+	// Client reads the IdentityPolicies and namespaces claims are checked
+	// against. Policies are not enforced when nil.
+	Client client.Reader
+	// AllowedIssuerGroups lists API groups of external issuers, such as
+	// awspca.cert-manager.io, that claims may reference besides cert-manager.io.
+	AllowedIssuerGroups []string
+}
+
+var _ admission.Validator[*identityv1alpha1.IdentityClaim] = &IdentityClaimCustomValidator{}
+
+// ValidateCreate implements admission.Validator
+func (v *IdentityClaimCustomValidator) ValidateCreate(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (admission.Warnings, error) {
+	identityclaimlog.V(1).Info("validating create", "name", claim.Name, "namespace", claim.Namespace)
+
+	allErrs := v.validateSpec(claim)
+	allErrs = append(allErrs, validateRotationRequest(nil, claim)...)
+	policyErrs, err := v.validatePolicies(ctx, claim)
+	if err != nil {
+		return nil, err
+	}
+	return nil, toError(claim, append(allErrs, policyErrs...))
+}
+
+// ValidateUpdate implements admission.Validator
+func (v *IdentityClaimCustomValidator) ValidateUpdate(ctx context.Context, oldClaim, claim *identityv1alpha1.IdentityClaim) (admission.Warnings, error) {
+	identityclaimlog.V(1).Info("validating update", "name", claim.Name, "namespace", claim.Namespace)
+
+	// Metadata-only updates, such as the controller adding or removing its
+	// finalizer, must not be blocked by claims created before the webhook.
+	rotationErrs := validateRotationRequest(oldClaim, claim)
+	if equality.Semantic.DeepEqual(oldClaim.Spec, claim.Spec) {
+		return nil, toError(claim, rotationErrs)
+	}
+	allErrs := append(v.validateSpec(claim), rotationErrs...)
+	allErrs = append(allErrs, validateImmutableFields(oldClaim, claim)...)
+	policyErrs, err := v.validatePolicies(ctx, claim)
+	if err != nil {
+		return nil, err
+	}
+	return nil, toError(claim, append(allErrs, policyErrs...))
+}
+
+// ValidateDelete implements admission.Validator
+func (v *IdentityClaimCustomValidator) ValidateDelete(_ context.Context, _ *identityv1alpha1.IdentityClaim) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateSpec checks the parts of the spec that the CRD schema can't express.
+func (v *IdentityClaimCustomValidator) validateSpec(claim *identityv1alpha1.IdentityClaim) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	selectorPath := specPath.Child("selector")
+	selector, err := metav1.LabelSelectorAsSelector(&claim.Spec.Selector)
+	switch {
+	case err != nil:
+		allErrs = append(allErrs, field.Invalid(selectorPath, claim.Spec.Selector, err.Error()))
+	case selector.Empty():
+		allErrs = append(allErrs, field.Required(selectorPath,
+			"must contain matchLabels or matchExpressions; an empty selector would match every pod in the namespace"))
+	}
+
+	if ref := claim.Spec.IssuerRef; ref != nil {
+		refPath := specPath.Child("issuerRef")
+		group := ref.Group
+		if group == "" {
+			group = certManagerGroup
+		}
+		switch {
+		case group == certManagerGroup:
+			if ref.Kind != "" && !slices.Contains(certManagerIssuerKinds, ref.Kind) {
+				allErrs = append(allErrs, field.NotSupported(refPath.Child("kind"), ref.Kind, certManagerIssuerKinds))
+			}
+		case !slices.Contains(v.AllowedIssuerGroups, group):
+			allErrs = append(allErrs, field.Forbidden(refPath.Child("group"),
+				"issuer group "+group+" is not in the operator's --allowed-issuer-groups"))
+		}
+	}
+
+	return allErrs
+}
+
+// validatePolicies checks the claim against the IdentityPolicies of its
+// namespace. Values the spec leaves to the operator, such as the default issuer
+// or a templated SPIFFE ID path, are enforced by the reconciler instead.
+func (v *IdentityClaimCustomValidator) validatePolicies(ctx context.Context, claim *identityv1alpha1.IdentityClaim) (field.ErrorList, error) {
+	if v.Client == nil {
+		return nil, nil
+	}
+	req := policy.NewRequest(claim)
+	if strings.Contains(req.SpiffeIDPath, "{{") {
+		req.SpiffeIDPath = ""
+	}
+	violation, err := policy.Evaluate(ctx, v.Client, claim.Namespace, req)
+	if err != nil || violation == nil {
+		return nil, err
+	}
+	return field.ErrorList{field.Forbidden(violation.Field,
+		"denied by IdentityPolicy "+violation.Policy+": "+violation.Detail)}, nil
+}
+
+// validateImmutableFields rejects changes to fields that can't change once the
+// claim has been issued.
+func validateImmutableFields(oldClaim, claim *identityv1alpha1.IdentityClaim) field.ErrorList {
+	var allErrs field.ErrorList
+	if claim.Spec.SecretName != oldClaim.Spec.SecretName {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "secretName"),
+			claim.Spec.SecretName, "secretName is immutable"))
+	}
+	return allErrs
+}
+
+// validateRotationRequest rejects a rotate-requested-at annotation that the
+// reconciler couldn't compare with the last rotation. Values left unchanged by
+// an update are not checked again.
+func validateRotationRequest(oldClaim, claim *identityv1alpha1.IdentityClaim) field.ErrorList {
+	value, ok := claim.Annotations[identityv1alpha1.RotateRequestedAtAnnotation]
+	if !ok || (oldClaim != nil && oldClaim.Annotations[identityv1alpha1.RotateRequestedAtAnnotation] == value) {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return field.ErrorList{field.Invalid(
+			field.NewPath("metadata", "annotations").Key(identityv1alpha1.RotateRequestedAtAnnotation),
+			value, "must be an RFC 3339 timestamp, such as 2026-01-02T15:04:05Z")}
+	}
+	return nil
+}
+
+// toError wraps allErrs into an Invalid API error, or returns nil when empty.
+func toError(claim *identityv1alpha1.IdentityClaim, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(identityv1alpha1.GroupVersion.WithKind("IdentityClaim").GroupKind(), claim.Name, allErrs)
+}
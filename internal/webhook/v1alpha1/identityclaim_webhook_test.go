@@ -0,0 +1,231 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	identityv1alpha1 "github.com/osagberg/identity-claim-operator/api/v1alpha1"
+)
+
+var _ = Describe("IdentityClaim Webhook", func() {
+	newClaim := func(name string) *identityv1alpha1.IdentityClaim {
+		return &identityv1alpha1.IdentityClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: identityv1alpha1.IdentityClaimSpec{
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+				TTL:      metav1.Duration{Duration: 1 * time.Hour},
+			},
+		}
+	}
+
+	deleteClaim := func(name string) {
+		claim := &identityv1alpha1.IdentityClaim{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, claim); err == nil {
+			Expect(k8sClient.Delete(ctx, claim)).To(Succeed())
+		}
+	}
+
+	Context("When the selector is empty", func() {
+		It("should be rejected at admission", func() {
+			claim := newClaim("empty-selector-claim")
+			claim.Spec.Selector = metav1.LabelSelector{}
+			err := k8sClient.Create(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.selector: Required value"))
+		})
+	})
+
+	Context("When the selector can't be parsed", func() {
+		It("should be rejected at admission", func() {
+			claim := newClaim("invalid-selector-claim")
+			claim.Spec.Selector = metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: "BadOperator", Values: []string{"test"}},
+				},
+			}
+			err := k8sClient.Create(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.selector: Invalid value"))
+		})
+	})
+
+	Context("When issuerRef has an unknown cert-manager kind", func() {
+		It("should be rejected at admission", func() {
+			claim := newClaim("unknown-kind-claim")
+			claim.Spec.IssuerRef = &identityv1alpha1.IssuerReference{
+				Name:  "my-issuer",
+				Kind:  "ClusterIssuers",
+				Group: "cert-manager.io",
+			}
+			err := k8sClient.Create(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`spec.issuerRef.kind: Unsupported value: "ClusterIssuers"`))
+		})
+	})
+
+	Context("When issuerRef uses an external issuer group", func() {
+		const allowedName = "allowed-group-claim"
+
+		AfterEach(func() {
+			deleteClaim(allowedName)
+		})
+
+		It("should reject groups outside the allowlist", func() {
+			claim := newClaim("forbidden-group-claim")
+			claim.Spec.IssuerRef = &identityv1alpha1.IssuerReference{
+				Name:  "my-issuer",
+				Kind:  "GoogleCASClusterIssuer",
+				Group: "cas-issuer.jetstack.io",
+			}
+			err := k8sClient.Create(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("issuer group cas-issuer.jetstack.io is not in the operator's --allowed-issuer-groups"))
+		})
+
+		It("should accept groups in the allowlist with any kind", func() {
+			claim := newClaim(allowedName)
+			claim.Spec.IssuerRef = &identityv1alpha1.IssuerReference{
+				Name:  "my-issuer",
+				Kind:  "AWSPCAClusterIssuer",
+				Group: allowedIssuerGroup,
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+		})
+	})
+
+	Context("When updating a claim", func() {
+		const resourceName = "update-claim"
+		nn := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			claim := newClaim(resourceName)
+			claim.Spec.SecretName = "update-claim-tls"
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim(resourceName)
+		})
+
+		It("should reject a changed secretName", func() {
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			claim.Spec.SecretName = "other-tls"
+			err := k8sClient.Update(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secretName is immutable"))
+		})
+
+		It("should reject an emptied selector", func() {
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			claim.Spec.Selector = metav1.LabelSelector{}
+			err := k8sClient.Update(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.selector: Required value"))
+		})
+
+		It("should allow changes to mutable fields and metadata", func() {
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			claim.Spec.TTL = metav1.Duration{Duration: 2 * time.Hour}
+			claim.Labels = map[string]string{"team": "payments"}
+			Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+		})
+
+		It("should only accept RFC 3339 rotation requests", func() {
+			claim := &identityv1alpha1.IdentityClaim{}
+			Expect(k8sClient.Get(ctx, nn, claim)).To(Succeed())
+			claim.Annotations = map[string]string{identityv1alpha1.RotateRequestedAtAnnotation: "yesterday"}
+			err := k8sClient.Update(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must be an RFC 3339 timestamp"))
+
+			claim.Annotations[identityv1alpha1.RotateRequestedAtAnnotation] = "2026-01-02T15:04:05Z"
+			Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+		})
+	})
+
+	Context("When validating an existing claim whose spec didn't change", func() {
+		It("should allow metadata-only updates even if the spec is invalid", func() {
+			validator := &IdentityClaimCustomValidator{}
+			claim := newClaim("legacy-claim")
+			claim.Spec.Selector = metav1.LabelSelector{}
+			updated := claim.DeepCopy()
+			updated.Finalizers = []string{"identity.cluster.local/finalizer"}
+
+			_, err := validator.ValidateUpdate(ctx, claim, updated)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = validator.ValidateCreate(ctx, claim)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When an IdentityPolicy applies to the namespace", func() {
+		const policyName = "default-namespace-policy"
+		const allowedName = "policy-allowed-claim"
+
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+				Spec: identityv1alpha1.IdentityPolicySpec{
+					NamespaceSelector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": "default"},
+					},
+					AllowedIssuers: []identityv1alpha1.IssuerReference{{Name: "team-issuer"}},
+					MaxTTL:         &metav1.Duration{Duration: 2 * time.Hour},
+				},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			deleteClaim(allowedName)
+			Expect(k8sClient.Delete(ctx, &identityv1alpha1.IdentityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: policyName},
+			})).To(Succeed())
+		})
+
+		It("should reject claims the policy denies, naming the policy", func() {
+			claim := newClaim("policy-denied-claim")
+			claim.Spec.TTL = metav1.Duration{Duration: 4 * time.Hour}
+			// The webhook reads policies from the manager's cache
+			Eventually(func() error {
+				return k8sClient.Create(ctx, claim.DeepCopy())
+			}).Should(MatchError(ContainSubstring(
+				"spec.ttl: Forbidden: denied by IdentityPolicy default-namespace-policy")))
+
+			claim = newClaim("policy-denied-claim")
+			claim.Spec.IssuerRef = &identityv1alpha1.IssuerReference{Name: "production-root"}
+			err := k8sClient.Create(ctx, claim)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.issuerRef: Forbidden"))
+		})
+
+		It("should accept claims the policy allows", func() {
+			claim := newClaim(allowedName)
+			claim.Spec.IssuerRef = &identityv1alpha1.IssuerReference{Name: "team-issuer"}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+		})
+	})
+})
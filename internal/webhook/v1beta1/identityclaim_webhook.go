@@ -0,0 +1,31 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	identityv1beta1 "github.com/osagberg/identity-claim-operator/api/v1beta1"
+)
+
+// SetupIdentityClaimWebhookWithManager registers the conversion webhook for
+// IdentityClaim in the manager. IdentityClaims are validated by the v1alpha1
+// webhook, which the API server also calls for v1beta1 requests.
+func SetupIdentityClaimWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &identityv1beta1.IdentityClaim{}).
+		Complete()
+}